@@ -1,16 +1,21 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"log/slog"
-	"maps"
+	"math/rand/v2"
+	"os"
 	"sync"
 	"time"
 
+	"gossip-glomers/internal/antientropy"
 	"gossip-glomers/internal/crdt"
-	"gossip-glomers/internal/tree"
+	"gossip-glomers/internal/gossip"
+	"gossip-glomers/internal/topology"
 
 	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
 )
@@ -27,32 +32,83 @@ type MessageBroadcast struct {
 
 type MessageBroadcastBatch struct {
 	BaseMessage
-	Set crdt.GSet `json:"set"`
+	Set  crdt.Compact[*crdt.RangeSet[int]] `json:"set"`
+	Boot gossip.Boot                       `json:"boot,omitempty"`
 }
 
+type MessageTopology struct {
+	BaseMessage
+	Topology map[string][]string `json:"topology"`
+}
+
+type MessageBloomReconcile struct {
+	BaseMessage
+	Filter antientropy.Bloom `json:"filter"`
+}
+
+type MessageBloomReconcileOk struct {
+	BaseMessage
+	Messages []int             `json:"messages"`
+	Filter   antientropy.Bloom `json:"filter"`
+}
+
+// Reconciliation modes: how the node repairs what delta gossip lost
+const (
+	reconcileMerkle = "merkle"
+	reconcileBloom  = "bloom"
+)
+
 type State struct {
 	n                *maelstrom.Node
+	overlay          string
 	branching        int
 	gossipTicker     time.Duration
+	antiEntropyTick  time.Duration
+	reconcileMode    string
+	bloomRate        float64
 	maxRetryAttempts int
 	requestTimeout   time.Duration
 	retryWait        time.Duration
 	mu               sync.Mutex
-	store            crdt.GSet
+	store            *crdt.RangeSet[int]
+	digest           *antientropy.Digest
+	bloomRounds      map[string]bloomRound
+	deltas           *crdt.DeltaBuffer[*crdt.RangeSet[int]]
+	boot             gossip.Boot
+	boots            gossip.Boots
+	sending          gossip.Inflight
 	peers            []string
-	wg               sync.WaitGroup
+	// start runs the gossip loops once, however often the topology is sent
+	start  sync.Once
+	wg     sync.WaitGroup
+	binary bool
 }
 
-func NewState(n *maelstrom.Node, branching int, batchTimer time.Duration) *State {
+func NewState(n *maelstrom.Node, overlay string, branching int, batchTimer time.Duration, reconcileMode string, binary bool) (*State, error) {
+	if reconcileMode != reconcileMerkle && reconcileMode != reconcileBloom {
+		return nil, fmt.Errorf("unknown reconciliation mode %q", reconcileMode)
+	}
+	if err := topology.Validate(overlay); err != nil {
+		return nil, err
+	}
 	return &State{
 		n:                n,
+		overlay:          overlay,
 		branching:        branching,
 		gossipTicker:     batchTimer,
+		antiEntropyTick:  2 * time.Second,
+		reconcileMode:    reconcileMode,
+		binary:           binary,
+		bloomRate:        0.01,
 		maxRetryAttempts: 3,
 		requestTimeout:   300 * time.Millisecond,
 		retryWait:        100 * time.Millisecond,
-		store:            make(crdt.GSet),
-	}
+		store:            crdt.NewRangeSet[int](),
+		deltas:           crdt.NewDeltaBuffer(nil, func() *crdt.RangeSet[int] { return crdt.NewRangeSet[int]() }),
+		digest:           antientropy.NewDigest(),
+		bloomRounds:      make(map[string]bloomRound),
+		boot:             gossip.NewBoot(),
+	}, nil
 }
 
 func (s *State) handleBroadcast(msg maelstrom.Message) error {
@@ -62,7 +118,8 @@ func (s *State) handleBroadcast(msg maelstrom.Message) error {
 	}
 
 	s.mu.Lock()
-	s.store.Add(body.Message)
+	s.deltas.Add(s.store.Add(body.Message))
+	s.digest.Add(body.Message)
 	s.mu.Unlock()
 
 	return s.n.Reply(msg, map[string]any{"type": "broadcast_ok"})
@@ -76,12 +133,68 @@ func (s *State) handleBroadcastBatch(msg maelstrom.Message) error {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	// A restarted peer needs every delta again
+	if s.boots.Restarted(msg.Src, body.Boot) {
+		slog.Info("peer restarted", slog.String("peer", msg.Src))
+		s.deltas.Reset(msg.Src)
+		delete(s.bloomRounds, msg.Src)
+	}
 
-	s.store.Merge(body.Set)
+	// Only the unseen part is relayed further down the tree, and not back to
+	// where it came from
+	fresh := crdt.NewRangeSet[int]()
+	for _, message := range body.Set.Value.Elements() {
+		if !s.store.Contains(message) {
+			fresh.Add(message)
+		}
+	}
+	if fresh.Len() > 0 {
+		s.store.Merge(fresh)
+		s.deltas.AddFrom(fresh, msg.Src)
+		s.digest.Add(fresh.Elements()...)
+	}
 
 	return s.n.Reply(msg, map[string]any{"type": "broadcast_batch_ok"})
 }
 
+func (s *State) handleBloomReconcile(msg maelstrom.Message) error {
+	var body MessageBloomReconcile
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	if err := body.Filter.Validate(); err != nil {
+		return maelstrom.NewRPCError(maelstrom.MalformedRequest, err.Error())
+	}
+
+	s.mu.Lock()
+	missing := s.missingFrom(msg.Src, &body.Filter)
+	filter := s.bloomFilter()
+	s.mu.Unlock()
+
+	return s.n.Reply(msg, map[string]any{
+		"type":     "bloom_reconcile_ok",
+		"messages": missing,
+		"filter":   filter,
+	})
+}
+
+// learn adds messages found through anti-entropy with peer and relays them
+// like broadcast ones, since the rest of the tree may be missing them too.
+func (s *State) learn(peer string, messages []int) {
+	if len(messages) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fresh := crdt.NewRangeSet[int]()
+	for _, message := range messages {
+		s.store.Add(message)
+		fresh.Add(message)
+	}
+	s.deltas.AddFrom(fresh, peer)
+	slog.Info("learned messages through anti-entropy", slog.Int("count", len(messages)))
+}
+
 func (s *State) handleRead(msg maelstrom.Message) error {
 	s.mu.Lock()
 	messages := s.store.Elements()
@@ -94,14 +207,28 @@ func (s *State) handleRead(msg maelstrom.Message) error {
 }
 
 func (s *State) handleTopology(msg maelstrom.Message) error {
-	treeTopology := tree.NewTree(s.n.NodeIDs(), s.branching)
-	peers := append(treeTopology.Children(s.n.ID()), treeTopology.Parent(s.n.ID()))
+	var body MessageTopology
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	overlay, err := topology.New(s.overlay, s.n.NodeIDs(), topology.Options{Branching: s.branching, Given: body.Topology})
+	if err != nil {
+		slog.Error("invalid topology", slog.String("error", err.Error()))
+		return maelstrom.NewRPCError(maelstrom.PreconditionFailed, err.Error())
+	}
+	peers := overlay.Peers(s.n.ID())
 
 	s.mu.Lock()
 	s.peers = peers
+	for _, peer := range peers {
+		s.deltas.AddPeer(peer)
+	}
 	s.mu.Unlock()
 
-	s.wg.Go(func() { s.runGossip() })
+	s.start.Do(func() {
+		s.wg.Go(s.runGossip)
+		s.wg.Go(s.runAntiEntropy)
+	})
 
 	slog.Info("received topology", slog.Any("peers", peers))
 	return s.n.Reply(msg, map[string]any{"type": "topology_ok"})
@@ -112,40 +239,192 @@ func (s *State) runGossip() {
 
 	for range ticker.C {
 		s.mu.Lock()
-		countersCopy := make(crdt.GSet)
-		maps.Copy(countersCopy, s.store)
+		peers := s.peers
+		s.mu.Unlock()
+
+		for _, peer := range peers {
+			s.mu.Lock()
+			// A peer still retrying the last send gets the new deltas with the
+			// next one
+			if !s.deltas.Pending(peer) || !s.sending.Start(peer) {
+				s.mu.Unlock()
+				continue
+			}
+			delta, seq, full := s.deltas.Since(peer)
+			s.mu.Unlock()
+
+			// A peer too far behind for deltas is repaired with an anti-entropy
+			// sync, which pushes what it lacks, instead of the full store
+			send := func() error { return s.syncWith(peer) }
+			if !full {
+				msg := MessageBroadcastBatch{
+					BaseMessage: BaseMessage{Type: "broadcast_batch"},
+					Set:         crdt.Compact[*crdt.RangeSet[int]]{Value: delta, Binary: s.binary},
+					Boot:        s.boot,
+				}
+				send = func() error { return s.sendWithRetry(peer, msg) }
+			}
+			s.wg.Go(func() {
+				err := send()
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.sending.Done(peer)
+				if err == nil {
+					s.deltas.Ack(peer, seq)
+				}
+			})
+		}
+	}
+}
+
+// runAntiEntropy periodically syncs digests with a random tree neighbour,
+// repairing whatever the delta gossip lost. Neighbours that are in sync cost
+// one round trip.
+func (s *State) runAntiEntropy() {
+	ticker := time.NewTicker(s.antiEntropyTick)
+
+	for range ticker.C {
+		s.mu.Lock()
+		peers := s.peers
 		s.mu.Unlock()
+		if len(peers) == 0 {
+			continue
+		}
+		_ = s.syncWith(peers[rand.IntN(len(peers))])
+	}
+}
+
+func (s *State) syncWith(peer string) error {
+	if s.reconcileMode == reconcileBloom {
+		return s.bloomSync(peer)
+	}
+	learned, err := s.digest.Sync(antientropy.NewRemote(s.n, peer, s.requestTimeout))
+	if err != nil {
+		slog.Error("anti-entropy failed", slog.String("peer", peer), slog.String("error", err.Error()))
+		return err
+	}
+	s.learn(peer, learned)
+	return nil
+}
 
-		msg := MessageBroadcastBatch{
-			BaseMessage: BaseMessage{Type: "broadcast_batch"},
-			Set:         countersCopy,
+// bloomSync swaps Bloom filters with peer: it learns the messages peer holds
+// that the local filter misses and pushes the local ones that the filter of
+// peer misses. Every filter has a fresh seed, so a message hidden by a false
+// positive is likely found in the next round.
+func (s *State) bloomSync(peer string) error {
+	s.mu.Lock()
+	filter := s.bloomFilter()
+	s.mu.Unlock()
+
+	var reply MessageBloomReconcileOk
+	err := s.call(peer, MessageBloomReconcile{
+		BaseMessage: BaseMessage{Type: "bloom_reconcile"},
+		Filter:      *filter,
+	}, &reply)
+	if err == nil {
+		err = reply.Filter.Validate()
+	}
+	if err != nil {
+		slog.Error("bloom reconciliation failed", slog.String("peer", peer), slog.String("error", err.Error()))
+		return err
+	}
+	s.learn(peer, s.digest.Add(reply.Messages...))
+
+	s.mu.Lock()
+	missing := s.missingFrom(peer, &reply.Filter)
+	s.mu.Unlock()
+	if len(missing) == 0 {
+		return nil
+	}
+	return s.sendWithRetry(peer, MessageBroadcastBatch{
+		BaseMessage: BaseMessage{Type: "broadcast_batch"},
+		Set:         crdt.Compact[*crdt.RangeSet[int]]{Value: crdt.NewRangeSet(missing...), Binary: s.binary},
+		Boot:        s.boot,
+	})
+}
+
+// bloomRound is a filter a peer sent and the local messages at the time, of
+// which the ones the filter contained were withheld from the peer.
+type bloomRound struct {
+	filter *antientropy.Bloom
+	store  *crdt.RangeSet[int]
+}
+
+// bloomFilter summarises the local messages with a fresh seed. Requires s.mu
+// to be held.
+func (s *State) bloomFilter() *antientropy.Bloom {
+	filter := antientropy.NewBloom(s.store.Len(), s.bloomRate, rand.Uint32())
+	for _, message := range s.store.Elements() {
+		filter.Add(message)
+	}
+	return filter
+}
+
+// missingFrom returns the local messages that filter, sent by peer, misses.
+// Filters have no false negatives, so peer lacks all of them; those withheld
+// in the previous round with peer are resent only because of a false positive
+// then, and are logged. Requires s.mu to be held.
+func (s *State) missingFrom(peer string, filter *antientropy.Bloom) []int {
+	missing := filter.Missing(s.store.Elements())
+	if previous, ok := s.bloomRounds[peer]; ok {
+		resent := 0
+		for _, message := range missing {
+			if previous.filter.Contains(message) && previous.store.Contains(message) {
+				resent++
+			}
 		}
-		for _, peer := range s.peers {
-			s.wg.Go(func() { s.sendWithRetry(peer, msg) })
+		if resent > 0 {
+			slog.Info("bloom false positives delayed messages",
+				slog.String("peer", peer),
+				slog.Int("retransmitted", resent),
+				slog.Int("sent", len(missing)))
 		}
 	}
+	s.bloomRounds[peer] = bloomRound{filter: filter, store: s.store.Copy()}
+	return missing
+}
+
+func (s *State) call(peer string, msg any, reply any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
+	defer cancel()
+	resp, err := s.n.SyncRPC(ctx, peer, msg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.Body, reply)
 }
 
-func (s *State) sendWithRetry(peer string, msg any) {
+func (s *State) sendWithRetry(peer string, msg any) error {
+	var err error
 	for attempt := 1; attempt < s.maxRetryAttempts; attempt++ {
 		ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
-		_, err := s.n.SyncRPC(ctx, peer, msg)
+		_, err = s.n.SyncRPC(ctx, peer, msg)
 		cancel()
 		if err == nil {
 			slog.Info("broadcasted to peer", slog.String("peer", peer), slog.Int("attempt", attempt))
-			return
+			return nil
 		}
 		slog.Error("failed to send to peer", slog.String("peer", peer), slog.String("error", err.Error()), slog.Int("attempt", attempt))
 		time.Sleep(s.retryWait)
 	}
+	return err
 }
 
 func main() {
 	n := maelstrom.NewNode()
-	state := NewState(n, 25, 200*time.Millisecond)
+	binary, err := crdt.ParseEncoding(cmp.Or(os.Getenv("ENCODING"), crdt.EncodingJSON))
+	if err != nil {
+		log.Fatal(err)
+	}
+	state, err := NewState(n, cmp.Or(os.Getenv("TOPOLOGY"), topology.NameTree), 25, 200*time.Millisecond, cmp.Or(os.Getenv("RECONCILE"), reconcileMerkle), binary)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	n.Handle("broadcast", state.handleBroadcast)
 	n.Handle("broadcast_batch", state.handleBroadcastBatch)
+	n.Handle("bloom_reconcile", state.handleBloomReconcile)
+	antientropy.Handle(n, state.digest, state.learn)
 	n.Handle("read", state.handleRead)
 	n.Handle("topology", state.handleTopology)
 
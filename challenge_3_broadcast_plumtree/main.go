@@ -0,0 +1,278 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"gossip-glomers/internal/antientropy"
+	"gossip-glomers/internal/crdt"
+	"gossip-glomers/internal/plumtree"
+	"gossip-glomers/internal/topology"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+type BaseMessage struct {
+	Type  string `json:"type"`
+	MsgID int    `json:"msg_id"`
+}
+
+type MessageBroadcast struct {
+	BaseMessage
+	Message int `json:"message"`
+}
+
+type MessageTopology struct {
+	BaseMessage
+	Topology map[string][]string `json:"topology"`
+}
+
+// MessagePlumtree carries the protocol messages between peers. Only gossip is
+// answered, so that pushes to a peer that is cut off time out and drop it
+// from the tree; the protocol repairs whatever else gets lost.
+type MessagePlumtree struct {
+	BaseMessage
+	plumtree.Message[int]
+}
+
+type State struct {
+	n               *maelstrom.Node
+	overlay         string
+	degree          int
+	tick            time.Duration
+	graftTimeout    time.Duration
+	retryTimeout    time.Duration
+	antiEntropyTick time.Duration
+	requestTimeout  time.Duration
+	mu              sync.Mutex
+	store           crdt.GSet[int]
+	digest          *antientropy.Digest
+	tree            *plumtree.Node[int]
+	peers           []string
+	wg              sync.WaitGroup
+
+	// A peer is dropped from the tree after suspectAfter pushes, grafts or
+	// anti-entropy syncs with it failed in a row, until it is reached again
+	suspectAfter int
+}
+
+func NewState(n *maelstrom.Node, overlay string, degree int, tick time.Duration) (*State, error) {
+	if err := topology.Validate(overlay); err != nil {
+		return nil, err
+	}
+	return &State{
+		n:               n,
+		overlay:         overlay,
+		degree:          degree,
+		tick:            tick,
+		graftTimeout:    6 * tick,
+		retryTimeout:    3 * tick,
+		antiEntropyTick: time.Second,
+		requestTimeout:  300 * time.Millisecond,
+		store:           make(crdt.GSet[int]),
+		digest:          antientropy.NewDigest(),
+		suspectAfter:    3,
+	}, nil
+}
+
+func (s *State) handleBroadcast(msg maelstrom.Message) error {
+	var body MessageBroadcast
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	s.spread(body.Message)
+	return s.n.Reply(msg, map[string]any{"type": "broadcast_ok"})
+}
+
+func (s *State) handlePlumtree(msg maelstrom.Message) error {
+	var body MessagePlumtree
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	if tree := s.plumtree(); tree != nil {
+		tree.Receive(msg.Src, body.Message)
+	}
+	if body.Kind != plumtree.KindGossip {
+		return nil
+	}
+	return s.n.Reply(msg, map[string]any{"type": "plumtree_ok"})
+}
+
+// spread broadcasts message over the tree. Before the topology is known it is
+// only kept locally, for anti-entropy to pass on later.
+func (s *State) spread(message int) {
+	if tree := s.plumtree(); tree != nil {
+		tree.Broadcast(message)
+		return
+	}
+	s.deliver(message)
+}
+
+// deliver is handed every message the tree receives for the first time.
+func (s *State) deliver(message int) {
+	s.mu.Lock()
+	s.store.Add(message)
+	s.mu.Unlock()
+	s.digest.Add(message)
+}
+
+func (s *State) plumtree() *plumtree.Node[int] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tree
+}
+
+func (s *State) send(peer string, msg plumtree.Message[int]) {
+	body := MessagePlumtree{BaseMessage: BaseMessage{Type: "plumtree"}, Message: msg}
+	if msg.Kind == plumtree.KindGossip {
+		s.wg.Go(func() { s.push(peer, body) })
+		return
+	}
+	if err := s.n.Send(peer, body); err != nil {
+		slog.Error("failed to send to peer", slog.String("peer", peer), slog.String("kind", string(msg.Kind)), slog.String("error", err.Error()))
+	}
+}
+
+// push sends gossip to peer and tells the tree whether peer answered.
+func (s *State) push(peer string, body MessagePlumtree) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
+	defer cancel()
+	_, err := s.n.SyncRPC(ctx, peer, body)
+	if err != nil {
+		slog.Error("push failed", slog.String("peer", peer), slog.String("error", err.Error()))
+	}
+	s.setFailed(peer, err != nil)
+}
+
+// learn broadcasts messages found through anti-entropy over the tree, since
+// the nodes behind this one lost them too.
+func (s *State) learn(messages []int) {
+	if len(messages) == 0 {
+		return
+	}
+	for _, message := range messages {
+		s.spread(message)
+	}
+	slog.Info("learned messages through anti-entropy", slog.Int("count", len(messages)))
+}
+
+func (s *State) handleRead(msg maelstrom.Message) error {
+	s.mu.Lock()
+	messages := s.store.Elements()
+	s.mu.Unlock()
+
+	return s.n.Reply(msg, map[string]any{
+		"type":     "read_ok",
+		"messages": messages,
+	})
+}
+
+func (s *State) handleTopology(msg maelstrom.Message) error {
+	var body MessageTopology
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	overlay, err := topology.New(s.overlay, s.n.NodeIDs(), topology.Options{Degree: s.degree, Given: body.Topology})
+	if err != nil {
+		slog.Error("invalid topology", slog.String("error", err.Error()))
+		return maelstrom.NewRPCError(maelstrom.PreconditionFailed, err.Error())
+	}
+	peers := overlay.Peers(s.n.ID())
+	tree := plumtree.NewNode(peers, s.send, s.deliver, plumtree.Config{
+		GraftTimeout: s.graftTimeout,
+		RetryTimeout: s.retryTimeout,
+		SuspectAfter: s.suspectAfter,
+	})
+
+	s.mu.Lock()
+	if s.tree != nil {
+		s.mu.Unlock()
+		return maelstrom.NewRPCError(maelstrom.PreconditionFailed, "topology already received")
+	}
+	s.peers = peers
+	s.tree = tree
+	s.mu.Unlock()
+
+	s.wg.Go(s.runTree)
+	s.wg.Go(s.runAntiEntropy)
+
+	slog.Info("received topology", slog.Any("peers", peers))
+	return s.n.Reply(msg, map[string]any{"type": "topology_ok"})
+}
+
+// runTree drives the timers of the tree: announcements to lazy peers and
+// grafts for overdue messages.
+func (s *State) runTree() {
+	ticker := time.NewTicker(s.tick)
+
+	for range ticker.C {
+		s.plumtree().Tick()
+	}
+}
+
+// runAntiEntropy periodically syncs digests with a random peer, repairing
+// whatever the tree lost, e.g. in a partition. Peers that are in sync cost
+// one round trip.
+func (s *State) runAntiEntropy() {
+	ticker := time.NewTicker(s.antiEntropyTick)
+
+	for range ticker.C {
+		s.mu.Lock()
+		peers := s.peers
+		s.mu.Unlock()
+		if len(peers) == 0 {
+			continue
+		}
+		_ = s.syncWith(peers[rand.IntN(len(peers))])
+	}
+}
+
+func (s *State) syncWith(peer string) error {
+	learned, err := s.digest.Sync(antientropy.NewRemote(s.n, peer, s.requestTimeout))
+	if err != nil {
+		slog.Error("anti-entropy failed", slog.String("peer", peer), slog.String("error", err.Error()))
+		s.setFailed(peer, true)
+		return err
+	}
+	s.setFailed(peer, false)
+	s.learn(learned)
+	return nil
+}
+
+// setFailed tells the tree whether a request to peer failed. The tree counts
+// grafts that time out itself. Anti-entropy keeps picking dropped peers, so
+// their recovery is noticed.
+func (s *State) setFailed(peer string, failed bool) {
+	tree := s.plumtree()
+	switch {
+	case failed && tree.Failed(peer):
+		slog.Info("suspected peer", slog.String("peer", peer), slog.Any("eager", tree.EagerPeers()))
+	case !failed && tree.Reached(peer):
+		slog.Info("recovered peer", slog.String("peer", peer), slog.Any("eager", tree.EagerPeers()))
+	}
+}
+
+func main() {
+	n := maelstrom.NewNode()
+	state, err := NewState(n, cmp.Or(os.Getenv("TOPOLOGY"), topology.NameRandomRegular), 4, 50*time.Millisecond)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	n.Handle("broadcast", state.handleBroadcast)
+	n.Handle("plumtree", state.handlePlumtree)
+	n.Handle("read", state.handleRead)
+	antientropy.Handle(n, state.digest, func(_ string, learned []int) { state.learn(learned) })
+	n.Handle("topology", state.handleTopology)
+
+	if err := n.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"log"
 	"log/slog"
+	"os"
 	"sync"
 	"time"
 
+	"gossip-glomers/internal/topology"
+
 	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
 )
 
@@ -46,6 +50,10 @@ func NewState() *State {
 func main() {
 	n := maelstrom.NewNode()
 	state := NewState()
+	overlay := cmp.Or(os.Getenv("TOPOLOGY"), topology.NameMaelstrom)
+	if err := topology.Validate(overlay); err != nil {
+		log.Fatal(err)
+	}
 
 	n.Handle("broadcast", func(msg maelstrom.Message) error {
 		var body MessageBroadcast
@@ -108,10 +116,16 @@ func main() {
 			return err
 		}
 
+		peers, err := topology.New(overlay, n.NodeIDs(), topology.Options{Given: body.Topology})
+		if err != nil {
+			slog.Error("invalid topology", slog.String("error", err.Error()))
+			return maelstrom.NewRPCError(maelstrom.PreconditionFailed, err.Error())
+		}
+
 		state.PeersMu.Lock()
 		defer state.PeersMu.Unlock()
-		state.Peers = body.Topology[n.ID()]
-		slog.Info("received topology", slog.Any("peers", body.Topology[n.ID()]))
+		state.Peers = peers.Peers(n.ID())
+		slog.Info("received topology", slog.Any("peers", state.Peers))
 		return n.Reply(msg, map[string]any{
 			"type": "topology_ok",
 		})
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"log"
 	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 
+	"gossip-glomers/internal/probe"
+	"gossip-glomers/internal/topology"
 	"gossip-glomers/internal/tree"
 
 	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
@@ -26,29 +33,82 @@ type MessageBroadcast struct {
 type MessageBroadcastBatch struct {
 	BaseMessage
 	Messages []int `json:"message"`
+	// Link asks the receiver to take the sender as a peer, so that an edge
+	// one node gained in a repair carries broadcasts both ways
+	Link bool `json:"link,omitempty"`
+}
+
+type MessageTopology struct {
+	BaseMessage
+	Topology map[string][]string `json:"topology"`
+}
+
+type MessageLatencies struct {
+	BaseMessage
+	RTTs map[string]time.Duration `json:"rtts"`
+}
+
+type MessageLatencyMatrix struct {
+	BaseMessage
+	Latencies tree.Latencies `json:"latencies"`
 }
 
 type State struct {
 	n          *maelstrom.Node
+	overlay    string
 	branching  int
+	trees      int
 	batchTimer time.Duration
 	mu         sync.Mutex
 	store      []int
 	seen       map[int]struct{}
+	topology   topology.Topology
 	peers      []string
 	batcher    map[string]chan int
 	wg         sync.WaitGroup
+
+	// A peer is suspected after suspectAfter failed attempts of a send and
+	// routed around until a send to it succeeds again. Nodes that took this
+	// node as a peer in such a repair are adopted back until the next
+	// topology
+	suspectAfter int
+	suspected    map[string]struct{}
+	adopted      map[string]struct{}
+
+	// A latency tree is rebuilt every probeTick from the round-trip times
+	// all nodes measured. Every node sends its times to the leader, which
+	// sends the collected matrix back, so that all nodes build the same tree.
+	// The prober is started once, however often the topology is sent
+	leader     string
+	prober     *probe.Prober
+	startProbe sync.Once
+	probeTick  time.Duration
+	latencies  tree.Latencies
 }
 
-func NewState(n *maelstrom.Node, branching int, batchTimer time.Duration) *State {
-	return &State{
-		n:          n,
-		branching:  branching,
-		batchTimer: batchTimer,
-		store:      make([]int, 0),
-		seen:       make(map[int]struct{}),
-		batcher:    make(map[string]chan int),
+func NewState(n *maelstrom.Node, overlay string, branching, trees int, batchTimer time.Duration) (*State, error) {
+	if err := topology.ValidateWithLatencies(overlay); err != nil {
+		return nil, err
+	}
+	s := &State{
+		n:            n,
+		overlay:      overlay,
+		branching:    branching,
+		trees:        trees,
+		batchTimer:   batchTimer,
+		store:        make([]int, 0),
+		seen:         make(map[int]struct{}),
+		batcher:      make(map[string]chan int),
+		suspectAfter: 3,
+		suspected:    make(map[string]struct{}),
+		adopted:      make(map[string]struct{}),
+		probeTick:    5 * time.Second,
+		latencies:    make(tree.Latencies),
+	}
+	if overlay == topology.NameLatencyTree {
+		s.prober = probe.New(s.ping, 3, time.Second)
 	}
+	return s, nil
 }
 
 func (s *State) handleBroadcast(msg maelstrom.Message) error {
@@ -65,14 +125,13 @@ func (s *State) handleBroadcast(msg maelstrom.Message) error {
 	}
 	s.store = append(s.store, body.Message)
 	s.seen[body.Message] = struct{}{}
-	s.mu.Unlock()
-
 	for _, peer := range s.peers {
 		if peer == msg.Src || peer == s.n.ID() {
 			continue
 		}
 		s.batcher[peer] <- body.Message
 	}
+	s.mu.Unlock()
 
 	return s.n.Reply(msg, map[string]any{"type": "broadcast_ok"})
 }
@@ -86,6 +145,13 @@ func (s *State) handleBroadcastBatch(msg maelstrom.Message) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if body.Link && !slices.Contains(s.peers, msg.Src) {
+		s.adopted[msg.Src] = struct{}{}
+		if err := s.updatePeers(); err != nil {
+			slog.Error("failed to adopt peer", slog.String("peer", msg.Src), slog.String("error", err.Error()))
+		}
+	}
+
 	for _, message := range body.Messages {
 		if _, ok := s.seen[message]; ok {
 			continue
@@ -116,22 +182,216 @@ func (s *State) handleRead(msg maelstrom.Message) error {
 }
 
 func (s *State) handleTopology(msg maelstrom.Message) error {
-	treeTopology := tree.NewTree(s.n.NodeIDs(), s.branching)
-	peers := append(treeTopology.Children(s.n.ID()), treeTopology.Parent(s.n.ID()))
+	var body MessageTopology
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	overlay, err := topology.New(s.overlay, s.n.NodeIDs(), topology.Options{Branching: s.branching, Trees: s.trees, Given: body.Topology})
+	if err != nil {
+		slog.Error("invalid topology", slog.String("error", err.Error()))
+		return maelstrom.NewRPCError(maelstrom.PreconditionFailed, err.Error())
+	}
 
 	s.mu.Lock()
-	s.peers = peers
-	for _, peer := range peers {
-		ch := make(chan int, 100)
-		s.batcher[peer] = ch
-		s.wg.Go(func() { s.runBatcher(peer, ch) })
-	}
+	s.topology = overlay
+	clear(s.adopted)
+	err = s.updatePeers()
+	peers := s.peers
 	s.mu.Unlock()
+	if err != nil {
+		slog.Error("invalid topology", slog.String("error", err.Error()))
+		return maelstrom.NewRPCError(maelstrom.PreconditionFailed, err.Error())
+	}
+
+	if s.prober != nil {
+		s.startProbe.Do(func() {
+			s.leader = slices.Min(s.n.NodeIDs())
+			s.wg.Go(s.runProbe)
+		})
+	}
 
 	slog.Info("received topology", slog.Any("peers", peers))
 	return s.n.Reply(msg, map[string]any{"type": "topology_ok"})
 }
 
+// updatePeers recomputes the neighbours of this node without the suspected
+// nodes. A tree is repaired around them; other shapes just drop them and rely
+// on their redundant links. Peers it gains, by adopting the children of a
+// failed node or by a suspected peer coming back, missed what was broadcast
+// while they were cut off, so they are sent the whole store. The batch is sent
+// even when the store is empty: it links the peer back, since the gained peer
+// suspects other nodes than this one does and so may not list this node as a
+// peer in its own repair. s.mu must be held.
+func (s *State) updatePeers() error {
+	peers, err := s.livePeers()
+	if err != nil {
+		return err
+	}
+	for _, peer := range peers {
+		if slices.Contains(s.peers, peer) {
+			continue
+		}
+		if _, ok := s.batcher[peer]; !ok {
+			ch := make(chan int, 100)
+			s.batcher[peer] = ch
+			s.wg.Go(func() { s.runBatcher(peer, ch) })
+		}
+		msg := MessageBroadcastBatch{
+			BaseMessage: BaseMessage{Type: "broadcast_batch"},
+			Messages:    slices.Clone(s.store),
+			Link:        true,
+		}
+		s.wg.Go(func() { s.sendWithRetry(peer, msg) })
+	}
+	s.peers = peers
+	return nil
+}
+
+func (s *State) livePeers() ([]string, error) {
+	var peers []string
+	if repairable, ok := s.topology.(topology.Repairable); ok {
+		live, err := repairable.LivePeers(s.n.ID(), slices.Collect(maps.Keys(s.suspected)))
+		if err != nil {
+			return nil, err
+		}
+		peers = live
+	} else if s.topology != nil {
+		peers = s.topology.Peers(s.n.ID())
+	}
+	for peer := range s.adopted {
+		if !slices.Contains(peers, peer) {
+			peers = append(peers, peer)
+		}
+	}
+	return slices.DeleteFunc(peers, func(peer string) bool {
+		_, ok := s.suspected[peer]
+		return ok
+	}), nil
+}
+
+func (s *State) handlePing(msg maelstrom.Message) error {
+	return s.n.Reply(msg, map[string]any{"type": "ping_ok"})
+}
+
+// handleLatencies collects the round-trip times a node measured, on the
+// leader.
+func (s *State) handleLatencies(msg maelstrom.Message) error {
+	var body MessageLatencies
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.latencies[msg.Src] = body.RTTs
+	s.mu.Unlock()
+	return nil
+}
+
+// handleLatencyMatrix rebuilds the tree from the times the leader collected.
+func (s *State) handleLatencyMatrix(msg maelstrom.Message) error {
+	var body MessageLatencyMatrix
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	s.rebuildTopology(body.Latencies)
+	return nil
+}
+
+func (s *State) ping(ctx context.Context, peer string) error {
+	_, err := s.n.SyncRPC(ctx, peer, map[string]any{"type": "ping"})
+	return err
+}
+
+// runProbe measures the round-trip times to all nodes at startup and every
+// probeTick after, and sends them to the leader. Every round the leader first
+// sends the times collected in the round before to all nodes, which rebuild
+// the tree from them. All nodes thus rebuild from the same matrix, and while
+// the leader is cut off they keep the tree they have.
+func (s *State) runProbe() {
+	ticker := time.NewTicker(s.probeTick)
+	defer ticker.Stop()
+
+	for {
+		s.shareLatencies()
+		<-ticker.C
+		if s.n.ID() == s.leader {
+			s.distributeLatencies()
+		}
+	}
+}
+
+func (s *State) shareLatencies() {
+	others := slices.DeleteFunc(slices.Clone(s.n.NodeIDs()), func(node string) bool { return node == s.n.ID() })
+	rtts := s.prober.Measure(others)
+	// Rounded times flap less between rounds, so the tree does too
+	for peer, rtt := range rtts {
+		rtts[peer] = rtt.Round(time.Millisecond)
+	}
+	if s.n.ID() == s.leader {
+		s.mu.Lock()
+		s.latencies[s.n.ID()] = rtts
+		s.mu.Unlock()
+		return
+	}
+	msg := MessageLatencies{BaseMessage: BaseMessage{Type: "latencies"}, RTTs: rtts}
+	if err := s.n.Send(s.leader, msg); err != nil {
+		slog.Error("failed to share latencies", slog.String("leader", s.leader), slog.String("error", err.Error()))
+	}
+}
+
+func (s *State) distributeLatencies() {
+	s.mu.Lock()
+	latencies := maps.Clone(s.latencies)
+	s.mu.Unlock()
+
+	msg := MessageLatencyMatrix{BaseMessage: BaseMessage{Type: "latency_matrix"}, Latencies: latencies}
+	for _, node := range s.n.NodeIDs() {
+		if node == s.n.ID() {
+			continue
+		}
+		if err := s.n.Send(node, msg); err != nil {
+			slog.Error("failed to distribute latencies", slog.String("node", node), slog.String("error", err.Error()))
+		}
+	}
+	s.rebuildTopology(latencies)
+}
+
+func (s *State) rebuildTopology(latencies tree.Latencies) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	overlay, err := topology.New(s.overlay, s.n.NodeIDs(), topology.Options{Branching: s.branching, Trees: s.trees, Latencies: latencies})
+	if err == nil {
+		s.topology = overlay
+		clear(s.adopted)
+		err = s.updatePeers()
+	}
+	if err != nil {
+		slog.Error("failed to rebuild topology", slog.String("error", err.Error()))
+		return
+	}
+	slog.Info("rebuilt topology from latencies", slog.Any("peers", s.peers))
+}
+
+// setSuspected marks peer as failed or recovered and reroutes around it.
+func (s *State) setSuspected(peer string, suspected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.suspected[peer]; ok == suspected {
+		return
+	}
+	if suspected {
+		s.suspected[peer] = struct{}{}
+	} else {
+		delete(s.suspected, peer)
+	}
+	if err := s.updatePeers(); err != nil {
+		slog.Error("failed to reroute", slog.String("error", err.Error()))
+		return
+	}
+	slog.Info("rerouted", slog.String("peer", peer), slog.Bool("suspected", suspected), slog.Any("peers", s.peers))
+}
+
 func (s *State) runBatcher(peer string, ch <-chan int) {
 	var batch []int
 	var timerCh <-chan time.Time
@@ -162,20 +422,38 @@ func (s *State) sendWithRetry(peer string, msg any) {
 		cancel()
 		if err == nil {
 			slog.Info("broadcasted to peer", slog.String("peer", peer), slog.Int("attempt", attempt))
+			if attempt > s.suspectAfter {
+				s.setSuspected(peer, false)
+			}
 			return
 		}
 		slog.Error("failed to send to peer", slog.String("peer", peer), slog.String("error", err.Error()), slog.Int("attempt", attempt))
+		if attempt == s.suspectAfter {
+			s.setSuspected(peer, true)
+		}
 	}
 }
 
 func main() {
 	n := maelstrom.NewNode()
-	state := NewState(n, 5, 25*time.Millisecond)
+	// TREES is the number of trees a tree topology forwards every message
+	// along: more trees cost more messages but survive more failures
+	trees, err := strconv.Atoi(cmp.Or(os.Getenv("TREES"), "1"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	state, err := NewState(n, cmp.Or(os.Getenv("TOPOLOGY"), topology.NameTree), 5, trees, 25*time.Millisecond)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	n.Handle("broadcast", state.handleBroadcast)
 	n.Handle("broadcast_batch", state.handleBroadcastBatch)
 	n.Handle("read", state.handleRead)
 	n.Handle("topology", state.handleTopology)
+	n.Handle("ping", state.handlePing)
+	n.Handle("latencies", state.handleLatencies)
+	n.Handle("latency_matrix", state.handleLatencyMatrix)
 
 	if err := n.Run(); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"gossip-glomers/internal/crdt"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+type BaseMessage struct {
+	Type  string `json:"type"`
+	MsgID int    `json:"msg_id"`
+}
+
+type MessageBroadcastState struct {
+	BaseMessage
+	State json.RawMessage `json:"state"`
+}
+
+type State struct {
+	n        *maelstrom.Node
+	crdtName string
+	replica  crdt.Replica
+	acked    map[string]crdt.Replica
+	peers    []string
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+
+	requestTimeout   time.Duration
+	broadcastTick    time.Duration
+	maxRetryAttempts int
+	retryWait        time.Duration
+}
+
+func NewState(n *maelstrom.Node, crdtName string) (*State, error) {
+	replica, err := crdt.New(crdtName)
+	if err != nil {
+		return nil, err
+	}
+	return &State{
+		n:                n,
+		crdtName:         crdtName,
+		replica:          replica,
+		acked:            make(map[string]crdt.Replica),
+		requestTimeout:   600 * time.Millisecond,
+		broadcastTick:    time.Second,
+		maxRetryAttempts: 5,
+		retryWait:        time.Millisecond * 100,
+	}, nil
+}
+
+func (s *State) handleUpdate(msg maelstrom.Message) error {
+	var body BaseMessage
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.replica.Apply(s.n.ID(), msg.Body); err != nil {
+		if errors.Is(err, crdt.ErrInsufficientRights) {
+			return maelstrom.NewRPCError(maelstrom.PreconditionFailed, err.Error())
+		}
+		return maelstrom.NewRPCError(maelstrom.MalformedRequest, err.Error())
+	}
+
+	return s.n.Reply(msg, map[string]any{"type": body.Type + "_ok"})
+}
+
+func (s *State) handleGossip(msg maelstrom.Message) error {
+	var body MessageBroadcastState
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	remote, err := crdt.New(s.crdtName)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body.State, remote); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.replica.Merge(remote); err != nil {
+		return err
+	}
+
+	return s.n.Reply(msg, map[string]any{"type": "broadcast_state_ok"})
+}
+
+func (s *State) handleRead(msg maelstrom.Message) error {
+	s.mu.Lock()
+	value := s.replica.Read()
+	s.mu.Unlock()
+
+	return s.n.Reply(msg, map[string]any{
+		"type":  "read_ok",
+		"value": value,
+	})
+}
+
+func (s *State) handleInit(_ maelstrom.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range s.n.NodeIDs() {
+		if n == s.n.ID() {
+			continue
+		}
+		s.peers = append(s.peers, n)
+	}
+	s.wg.Go(s.runGossip)
+	return nil
+}
+
+func (s *State) runGossip() {
+	ticker := time.NewTicker(s.broadcastTick)
+
+	for range ticker.C {
+		s.mu.Lock()
+		snapshot := s.replica.Copy()
+		s.mu.Unlock()
+
+		state, err := json.Marshal(snapshot)
+		if err != nil {
+			slog.Error("failed to encode state", slog.String("error", err.Error()))
+			continue
+		}
+		msg := MessageBroadcastState{
+			BaseMessage: BaseMessage{Type: "broadcast_state"},
+			State:       state,
+		}
+		for _, peer := range s.peers {
+			s.mu.Lock()
+			acked := s.acked[peer]
+			s.mu.Unlock()
+			// The peer already merged this exact state
+			if acked != nil && acked.Equal(snapshot) {
+				continue
+			}
+			s.wg.Go(func() {
+				if err := s.sendWithRetry(peer, msg); err != nil {
+					return
+				}
+				s.mu.Lock()
+				s.acked[peer] = snapshot
+				s.mu.Unlock()
+			})
+		}
+	}
+}
+
+func (s *State) sendWithRetry(peer string, msg any) error {
+	var err error
+	for attempt := 1; attempt < s.maxRetryAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
+		_, err = s.n.SyncRPC(ctx, peer, msg)
+		cancel()
+		if err == nil {
+			slog.Info("broadcasted to peer", slog.String("peer", peer), slog.Int("attempt", attempt))
+			return nil
+		}
+		slog.Error("failed to send to peer", slog.String("peer", peer), slog.String("error", err.Error()), slog.Int("attempt", attempt))
+		time.Sleep(s.retryWait)
+	}
+	return err
+}
+
+func main() {
+	n := maelstrom.NewNode()
+	state, err := NewState(n, cmp.Or(os.Getenv("CRDT"), "g-counter"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	n.Handle("add", state.handleUpdate)
+	n.Handle("remove", state.handleUpdate)
+	n.Handle("transfer", state.handleUpdate)
+	n.Handle("read", state.handleRead)
+	n.Handle("broadcast_state", state.handleGossip)
+	n.Handle("init", state.handleInit)
+
+	if err := n.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
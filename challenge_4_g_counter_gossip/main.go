@@ -1,11 +1,13 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"log"
 	"log/slog"
 	"maps"
+	"os"
 	"sync"
 	"time"
 
@@ -26,13 +28,14 @@ type MessageAdd struct {
 
 type MessageBroadcastCounters struct {
 	BaseMessage
-	Counters crdt.GCounter `json:"counters"`
+	Counters crdt.Compact[crdt.GCounter[string, int]] `json:"counters"`
 }
 
 type State struct {
 	n       *maelstrom.Node
-	counter crdt.GCounter
+	counter crdt.GCounter[string, int]
 	peers   []string
+	binary  bool
 	mu      sync.Mutex
 	wg      sync.WaitGroup
 
@@ -42,10 +45,11 @@ type State struct {
 	retryWait             time.Duration
 }
 
-func NewState(n *maelstrom.Node) *State {
+func NewState(n *maelstrom.Node, binary bool) *State {
 	return &State{
 		n:                     n,
-		counter:               make(crdt.GCounter),
+		binary:                binary,
+		counter:               make(crdt.GCounter[string, int]),
 		requestTimeout:        600 * time.Millisecond,
 		broadcastCountersTick: time.Second,
 		maxRetryAttempts:      5,
@@ -74,7 +78,7 @@ func (s *State) handleGossip(msg maelstrom.Message) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.counter.Merge(body.Counters)
+	s.counter.Merge(body.Counters.Value)
 
 	return s.n.Reply(msg, map[string]any{"type": "broadcast_counters_ok"})
 }
@@ -110,13 +114,13 @@ func (s *State) runGossip() {
 
 	for range ticker.C {
 		s.mu.Lock()
-		countersCopy := make(crdt.GCounter)
+		countersCopy := make(crdt.GCounter[string, int])
 		maps.Copy(countersCopy, s.counter)
 		s.mu.Unlock()
 
 		msg := MessageBroadcastCounters{
 			BaseMessage: BaseMessage{Type: "broadcast_counters"},
-			Counters:    countersCopy,
+			Counters:    crdt.Compact[crdt.GCounter[string, int]]{Value: countersCopy, Binary: s.binary},
 		}
 		for _, peer := range s.peers {
 			s.wg.Go(func() { s.sendWithRetry(peer, msg) })
@@ -140,7 +144,11 @@ func (s *State) sendWithRetry(peer string, msg any) {
 
 func main() {
 	n := maelstrom.NewNode()
-	state := NewState(n)
+	binary, err := crdt.ParseEncoding(cmp.Or(os.Getenv("ENCODING"), crdt.EncodingJSON))
+	if err != nil {
+		log.Fatal(err)
+	}
+	state := NewState(n, binary)
 
 	n.Handle("add", state.handleAdd)
 	n.Handle("read", state.handleRead)
@@ -1,15 +1,19 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"log"
 	"log/slog"
-	"maps"
+	"math/rand/v2"
+	"os"
 	"sync"
 	"time"
 
+	"gossip-glomers/internal/antientropy"
 	"gossip-glomers/internal/crdt"
+	"gossip-glomers/internal/gossip"
 
 	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
 )
@@ -26,28 +30,41 @@ type MessageAdd struct {
 
 type MessageBroadcastSet struct {
 	BaseMessage
-	Set crdt.GSet `json:"set"`
+	Set  crdt.Compact[crdt.GSet[int]] `json:"set"`
+	Boot gossip.Boot                  `json:"boot,omitempty"`
 }
 
 type State struct {
-	n     *maelstrom.Node
-	set   crdt.GSet
-	peers []string
-	mu    sync.Mutex
-	wg    sync.WaitGroup
+	n       *maelstrom.Node
+	set     crdt.GSet[int]
+	digest  *antientropy.Digest
+	deltas  *crdt.DeltaBuffer[crdt.GSet[int]]
+	boot    gossip.Boot
+	boots   gossip.Boots
+	sending gossip.Inflight
+	peers   []string
+	binary  bool
+	mu      sync.Mutex
+	wg      sync.WaitGroup
 
 	requestTimeout   time.Duration
 	broadcastTick    time.Duration
+	antiEntropyTick  time.Duration
 	maxRetryAttempts int
 	retryWait        time.Duration
 }
 
-func NewState(n *maelstrom.Node) *State {
+func NewState(n *maelstrom.Node, binary bool) *State {
 	return &State{
 		n:                n,
-		set:              make(crdt.GSet),
+		binary:           binary,
+		set:              make(crdt.GSet[int]),
+		digest:           antientropy.NewDigest(),
+		deltas:           crdt.NewDeltaBuffer(nil, func() crdt.GSet[int] { return make(crdt.GSet[int]) }),
+		boot:             gossip.NewBoot(),
 		requestTimeout:   600 * time.Millisecond,
 		broadcastTick:    time.Second,
+		antiEntropyTick:  5 * time.Second,
 		maxRetryAttempts: 5,
 		retryWait:        time.Millisecond * 100,
 	}
@@ -61,7 +78,8 @@ func (s *State) handleAdd(msg maelstrom.Message) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.set.Add(body.Element)
+	s.deltas.Add(s.set.Add(body.Element))
+	s.digest.Add(body.Element)
 
 	return s.n.Reply(msg, map[string]any{"type": "add_ok"})
 }
@@ -74,11 +92,31 @@ func (s *State) handleGossip(msg maelstrom.Message) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.set.Merge(body.Set)
+	s.set.Merge(body.Set.Value)
+	s.digest.Add(body.Set.Value.Elements()...)
+	// A restarted peer needs every delta again
+	if s.boots.Restarted(msg.Src, body.Boot) {
+		slog.Info("peer restarted", slog.String("peer", msg.Src))
+		s.deltas.Reset(msg.Src)
+	}
 
 	return s.n.Reply(msg, map[string]any{"type": "broadcast_set_ok"})
 }
 
+// learn adds elements found through anti-entropy. Every node gossips to all
+// others, so they need not be passed on.
+func (s *State) learn(elements []int) {
+	if len(elements) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, element := range elements {
+		s.set.Add(element)
+	}
+	slog.Info("learned elements through anti-entropy", slog.Int("count", len(elements)))
+}
+
 func (s *State) handleRead(msg maelstrom.Message) error {
 	s.mu.Lock()
 	elements := s.set.Elements()
@@ -99,8 +137,10 @@ func (s *State) handleInit(_ maelstrom.Message) error {
 			continue
 		}
 		s.peers = append(s.peers, n)
+		s.deltas.AddPeer(n)
 	}
 	s.wg.Go(s.runGossip)
+	s.wg.Go(s.runAntiEntropy)
 	return nil
 }
 
@@ -108,41 +148,91 @@ func (s *State) runGossip() {
 	ticker := time.NewTicker(s.broadcastTick)
 
 	for range ticker.C {
-		s.mu.Lock()
-		setCopy := make(crdt.GSet)
-		maps.Copy(setCopy, s.set)
-		s.mu.Unlock()
-
-		msg := MessageBroadcastSet{
-			BaseMessage: BaseMessage{Type: "broadcast_set"},
-			Set:         setCopy,
-		}
 		for _, peer := range s.peers {
-			s.wg.Go(func() { s.sendWithRetry(peer, msg) })
+			s.mu.Lock()
+			// A peer still retrying the last send gets the new deltas with the
+			// next one
+			if !s.deltas.Pending(peer) || !s.sending.Start(peer) {
+				s.mu.Unlock()
+				continue
+			}
+			delta, seq, full := s.deltas.Since(peer)
+			s.mu.Unlock()
+
+			// A peer too far behind for deltas is repaired with a digest sync
+			// instead of the full set
+			send := func() error { return s.syncWith(peer) }
+			if !full {
+				msg := MessageBroadcastSet{
+					BaseMessage: BaseMessage{Type: "broadcast_set"},
+					Set:         crdt.Compact[crdt.GSet[int]]{Value: delta, Binary: s.binary},
+					Boot:        s.boot,
+				}
+				send = func() error { return s.sendWithRetry(peer, msg) }
+			}
+			s.wg.Go(func() {
+				err := send()
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.sending.Done(peer)
+				if err == nil {
+					s.deltas.Ack(peer, seq)
+				}
+			})
+		}
+	}
+}
+
+// runAntiEntropy periodically syncs digests with a random peer, repairing
+// whatever the delta gossip lost. Peers that are in sync cost one round trip.
+func (s *State) runAntiEntropy() {
+	ticker := time.NewTicker(s.antiEntropyTick)
+
+	for range ticker.C {
+		if len(s.peers) == 0 {
+			continue
 		}
+		_ = s.syncWith(s.peers[rand.IntN(len(s.peers))])
 	}
 }
 
-func (s *State) sendWithRetry(peer string, msg any) {
+func (s *State) syncWith(peer string) error {
+	learned, err := s.digest.Sync(antientropy.NewRemote(s.n, peer, s.requestTimeout))
+	if err != nil {
+		slog.Error("anti-entropy failed", slog.String("peer", peer), slog.String("error", err.Error()))
+		return err
+	}
+	s.learn(learned)
+	return nil
+}
+
+func (s *State) sendWithRetry(peer string, msg any) error {
+	var err error
 	for attempt := 1; attempt < s.maxRetryAttempts; attempt++ {
 		ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
-		_, err := s.n.SyncRPC(ctx, peer, msg)
+		_, err = s.n.SyncRPC(ctx, peer, msg)
 		cancel()
 		if err == nil {
 			slog.Info("broadcasted to peer", slog.String("peer", peer), slog.Int("attempt", attempt))
-			return
+			return nil
 		}
 		slog.Error("failed to send to peer", slog.String("peer", peer), slog.String("error", err.Error()), slog.Int("attempt", attempt))
 		time.Sleep(s.retryWait)
 	}
+	return err
 }
 
 func main() {
 	n := maelstrom.NewNode()
-	state := NewState(n)
+	binary, err := crdt.ParseEncoding(cmp.Or(os.Getenv("ENCODING"), crdt.EncodingJSON))
+	if err != nil {
+		log.Fatal(err)
+	}
+	state := NewState(n, binary)
 
 	n.Handle("add", state.handleAdd)
 	n.Handle("read", state.handleRead)
+	antientropy.Handle(n, state.digest, func(_ string, learned []int) { state.learn(learned) })
 	n.Handle("broadcast_set", state.handleGossip)
 	n.Handle("init", state.handleInit)
 
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gossip-glomers/internal/crdt"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+type BaseMessage struct {
+	Type  string `json:"type"`
+	MsgID int    `json:"msg_id"`
+}
+
+type MessageElement struct {
+	BaseMessage
+	Element int `json:"element"`
+}
+
+type MessageBroadcastSet struct {
+	BaseMessage
+	Set crdt.ORSet[int] `json:"set"`
+}
+
+type State struct {
+	n     *maelstrom.Node
+	set   crdt.ORSet[int]
+	peers []string
+	// stability learns from the clocks peers gossip which removes every node
+	// has seen, so their tombstones can be dropped
+	stability *crdt.StabilityTracker
+	mu        sync.Mutex
+	wg        sync.WaitGroup
+
+	requestTimeout   time.Duration
+	broadcastTick    time.Duration
+	maxRetryAttempts int
+	retryWait        time.Duration
+}
+
+func NewState(n *maelstrom.Node) *State {
+	return &State{
+		n:                n,
+		set:              crdt.NewORSet[int](),
+		stability:        crdt.NewStabilityTracker(nil),
+		requestTimeout:   600 * time.Millisecond,
+		broadcastTick:    time.Second,
+		maxRetryAttempts: 5,
+		retryWait:        time.Millisecond * 100,
+	}
+}
+
+func (s *State) handleAdd(msg maelstrom.Message) error {
+	var body MessageElement
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set.Add(s.n.ID(), body.Element)
+
+	return s.n.Reply(msg, map[string]any{"type": "add_ok"})
+}
+
+// handleRemove removes the element as far as this node has seen it added: an
+// add elsewhere that it has not merged yet survives.
+func (s *State) handleRemove(msg maelstrom.Message) error {
+	var body MessageElement
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set.Remove(s.n.ID(), body.Element)
+
+	return s.n.Reply(msg, map[string]any{"type": "remove_ok"})
+}
+
+func (s *State) handleGossip(msg maelstrom.Message) error {
+	var body MessageBroadcastSet
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set.Merge(body.Set)
+	// The sender has seen every event in its clock
+	s.stability.Observe(msg.Src, body.Set.Clock)
+
+	return s.n.Reply(msg, map[string]any{"type": "broadcast_set_ok"})
+}
+
+func (s *State) handleRead(msg maelstrom.Message) error {
+	s.mu.Lock()
+	elements := s.set.Elements()
+	s.mu.Unlock()
+
+	return s.n.Reply(msg, map[string]any{
+		"type":  "read_ok",
+		"value": elements,
+	})
+}
+
+func (s *State) handleInit(_ maelstrom.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range s.n.NodeIDs() {
+		s.stability.Track(n)
+		if n == s.n.ID() {
+			continue
+		}
+		s.peers = append(s.peers, n)
+	}
+	s.wg.Go(s.runGossip)
+	return nil
+}
+
+func (s *State) runGossip() {
+	ticker := time.NewTicker(s.broadcastTick)
+
+	for range ticker.C {
+		s.mu.Lock()
+		s.stability.Observe(s.n.ID(), s.set.Clock)
+		s.set.Compact(s.stability.Stable())
+		setCopy := s.set.Copy()
+		s.mu.Unlock()
+
+		msg := MessageBroadcastSet{
+			BaseMessage: BaseMessage{Type: "broadcast_set"},
+			Set:         setCopy,
+		}
+		for _, peer := range s.peers {
+			s.wg.Go(func() { s.sendWithRetry(peer, msg) })
+		}
+	}
+}
+
+func (s *State) sendWithRetry(peer string, msg any) {
+	for attempt := 1; attempt < s.maxRetryAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
+		_, err := s.n.SyncRPC(ctx, peer, msg)
+		cancel()
+		if err == nil {
+			slog.Info("broadcasted to peer", slog.String("peer", peer), slog.Int("attempt", attempt))
+			return
+		}
+		slog.Error("failed to send to peer", slog.String("peer", peer), slog.String("error", err.Error()), slog.Int("attempt", attempt))
+		time.Sleep(s.retryWait)
+	}
+}
+
+func main() {
+	n := maelstrom.NewNode()
+	state := NewState(n)
+
+	n.Handle("add", state.handleAdd)
+	n.Handle("remove", state.handleRemove)
+	n.Handle("read", state.handleRead)
+	n.Handle("broadcast_set", state.handleGossip)
+	n.Handle("init", state.handleInit)
+
+	if err := n.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
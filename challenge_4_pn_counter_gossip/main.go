@@ -1,14 +1,17 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"log"
 	"log/slog"
+	"os"
 	"sync"
 	"time"
 
 	"gossip-glomers/internal/crdt"
+	"gossip-glomers/internal/gossip"
 
 	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
 )
@@ -25,13 +28,19 @@ type MessageAdd struct {
 
 type MessageBroadcastCounters struct {
 	BaseMessage
-	Counters crdt.PNCounter `json:"counters"`
+	Counters crdt.Compact[crdt.PNCounter[string, int]] `json:"counters"`
+	Boot     gossip.Boot                               `json:"boot,omitempty"`
 }
 
 type State struct {
 	n       *maelstrom.Node
-	counter crdt.PNCounter
+	counter crdt.PNCounter[string, int]
+	deltas  *crdt.DeltaBuffer[crdt.PNCounter[string, int]]
+	boot    gossip.Boot
+	boots   gossip.Boots
+	sending gossip.Inflight
 	peers   []string
+	binary  bool
 	mu      sync.Mutex
 	wg      sync.WaitGroup
 
@@ -41,10 +50,13 @@ type State struct {
 	retryWait             time.Duration
 }
 
-func NewState(n *maelstrom.Node) *State {
+func NewState(n *maelstrom.Node, binary bool) *State {
 	return &State{
 		n:                     n,
-		counter:               crdt.NewPNCounter(),
+		binary:                binary,
+		counter:               crdt.NewPNCounter[string, int](),
+		deltas:                crdt.NewDeltaBuffer(nil, crdt.NewPNCounter[string, int]),
+		boot:                  gossip.NewBoot(),
 		requestTimeout:        600 * time.Millisecond,
 		broadcastCountersTick: time.Second,
 		maxRetryAttempts:      5,
@@ -60,7 +72,7 @@ func (s *State) handleAdd(msg maelstrom.Message) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.counter.Increment(s.n.ID(), body.Delta)
+	s.deltas.Add(s.counter.Increment(s.n.ID(), body.Delta))
 
 	return s.n.Reply(msg, map[string]any{"type": "add_ok"})
 }
@@ -73,7 +85,12 @@ func (s *State) handleGossip(msg maelstrom.Message) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.counter.Merge(body.Counters)
+	s.counter.Merge(body.Counters.Value)
+	// A restarted peer needs every delta again
+	if s.boots.Restarted(msg.Src, body.Boot) {
+		slog.Info("peer restarted", slog.String("peer", msg.Src))
+		s.deltas.Reset(msg.Src)
+	}
 
 	return s.n.Reply(msg, map[string]any{"type": "broadcast_counters_ok"})
 }
@@ -99,6 +116,7 @@ func (s *State) handleInit(_ maelstrom.Message) error {
 		}
 		s.counter.Increment(n, 0)
 		s.peers = append(s.peers, n)
+		s.deltas.AddPeer(n)
 	}
 	s.wg.Go(s.runGossip)
 	return nil
@@ -108,37 +126,61 @@ func (s *State) runGossip() {
 	ticker := time.NewTicker(s.broadcastCountersTick)
 
 	for range ticker.C {
-		s.mu.Lock()
-		countersCopy := s.counter.Copy()
-		s.mu.Unlock()
-
-		msg := MessageBroadcastCounters{
-			BaseMessage: BaseMessage{Type: "broadcast_counters"},
-			Counters:    countersCopy,
-		}
 		for _, peer := range s.peers {
-			s.wg.Go(func() { s.sendWithRetry(peer, msg) })
+			s.mu.Lock()
+			// A peer still retrying the last send gets the new deltas with the
+			// next one
+			if !s.deltas.Pending(peer) || !s.sending.Start(peer) {
+				s.mu.Unlock()
+				continue
+			}
+			delta, seq, full := s.deltas.Since(peer)
+			if full {
+				delta = s.counter.Copy()
+			}
+			s.mu.Unlock()
+
+			msg := MessageBroadcastCounters{
+				BaseMessage: BaseMessage{Type: "broadcast_counters"},
+				Counters:    crdt.Compact[crdt.PNCounter[string, int]]{Value: delta, Binary: s.binary},
+				Boot:        s.boot,
+			}
+			s.wg.Go(func() {
+				err := s.sendWithRetry(peer, msg)
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.sending.Done(peer)
+				if err == nil {
+					s.deltas.Ack(peer, seq)
+				}
+			})
 		}
 	}
 }
 
-func (s *State) sendWithRetry(peer string, msg any) {
+func (s *State) sendWithRetry(peer string, msg any) error {
+	var err error
 	for attempt := 1; attempt < s.maxRetryAttempts; attempt++ {
 		ctx, cancel := context.WithTimeout(context.Background(), s.requestTimeout)
-		_, err := s.n.SyncRPC(ctx, peer, msg)
+		_, err = s.n.SyncRPC(ctx, peer, msg)
 		cancel()
 		if err == nil {
 			slog.Info("broadcasted to peer", slog.String("peer", peer), slog.Int("attempt", attempt))
-			return
+			return nil
 		}
 		slog.Error("failed to send to peer", slog.String("peer", peer), slog.String("error", err.Error()), slog.Int("attempt", attempt))
 		time.Sleep(s.retryWait)
 	}
+	return err
 }
 
 func main() {
 	n := maelstrom.NewNode()
-	state := NewState(n)
+	binary, err := crdt.ParseEncoding(cmp.Or(os.Getenv("ENCODING"), crdt.EncodingJSON))
+	if err != nil {
+		log.Fatal(err)
+	}
+	state := NewState(n, binary)
 
 	n.Handle("add", state.handleAdd)
 	n.Handle("read", state.handleRead)
@@ -0,0 +1,104 @@
+// Command overlay prints the overlay broadcast nodes build, to see what a
+// topology actually looks like. It reads the node IDs from a Maelstrom init
+// message, builds the topology the way every node does on its own and prints
+// the resulting graph with its depth and fan-out.
+//
+//	go run ./cmd/overlay -shape tree -branching 5 | dot -Tsvg > tree.svg
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gossip-glomers/internal/topology"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+type MessageTopology struct {
+	Topology map[string][]string `json:"topology"`
+}
+
+type Output struct {
+	Shape string         `json:"shape"`
+	Graph topology.Graph `json:"graph"`
+	Stats topology.Stats `json:"stats"`
+}
+
+func readBody(path string, body any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var msg maelstrom.Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := json.Unmarshal(msg.Body, body); err != nil {
+		return fmt.Errorf("parse body of %s: %w", path, err)
+	}
+	return nil
+}
+
+func main() {
+	initPath := flag.String("init", "test/init.json", "Maelstrom init message with the node IDs")
+	topologyPath := flag.String("topology", "", "Maelstrom topology message, needed for the maelstrom shape")
+	shape := flag.String("shape", topology.NameTree, fmt.Sprintf("overlay shape, one of %v", topology.Names))
+	format := flag.String("format", "dot", "output format, dot or json")
+	var opts topology.Options
+	flag.IntVar(&opts.Branching, "branching", 5, "children of inner tree nodes, 5 in the d node and 25 in the crdt node")
+	flag.IntVar(&opts.Trees, "trees", 1, "number of trees with disjoint inner nodes")
+	flag.IntVar(&opts.Degree, "degree", 0, "peers per node of random-regular and small-world graphs")
+	flag.Float64Var(&opts.Rewire, "rewire", 0, "rewiring probability of small-world graphs")
+	flag.Uint64Var(&opts.Seed, "seed", 0, "seed of the random shapes")
+	flag.Parse()
+
+	// There are no latencies to build a latency tree from
+	if err := topology.Validate(*shape); err != nil {
+		log.Fatal(err)
+	}
+
+	var init maelstrom.InitMessageBody
+	if err := readBody(*initPath, &init); err != nil {
+		log.Fatal(err)
+	}
+	if *topologyPath != "" {
+		var given MessageTopology
+		if err := readBody(*topologyPath, &given); err != nil {
+			log.Fatal(err)
+		}
+		opts.Given = given.Topology
+	}
+
+	// Every node builds the same overlay from the same node IDs and options
+	overlay, err := topology.New(*shape, init.NodeIDs, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	graph := topology.Snapshot(overlay, init.NodeIDs)
+	stats := graph.Stats()
+
+	switch *format {
+	case "dot":
+		if err := graph.WriteDOT(os.Stdout, *shape); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(os.Stderr, "%d nodes, %d links\n", stats.Nodes, stats.Links)
+		fmt.Fprintf(os.Stderr, "fan-out: min %d, mean %.2f, max %d\n", stats.MinFanOut, stats.MeanFanOut, stats.MaxFanOut)
+		fmt.Fprintf(os.Stderr, "depth: mean %.2f, max %d\n", stats.MeanDepth, stats.MaxDepth)
+		if stats.Unreachable > 0 {
+			fmt.Fprintf(os.Stderr, "%d pairs of nodes cannot reach each other\n", stats.Unreachable)
+		}
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(Output{Shape: *shape, Graph: graph, Stats: stats}); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("unknown format %q", *format)
+	}
+}
@@ -0,0 +1,84 @@
+package antientropy
+
+import (
+	"errors"
+	"math"
+)
+
+// maxBloomHashes bounds the hash count accepted from a peer's filter.
+const maxBloomHashes = 32
+
+// Bloom is a Bloom filter over ints, summarising a set in a few bits per
+// element. Contains has no false negatives: an element it rejects is certainly
+// not in the summarised set. Seed salts the hash functions, so filters of the
+// same set built with different seeds have independent false positives.
+type Bloom struct {
+	Bits   []byte `json:"bits"`
+	Hashes int    `json:"hashes"`
+	Seed   uint32 `json:"seed"`
+}
+
+// NewBloom sizes a filter for n elements with the given false positive rate.
+func NewBloom(n int, falsePositiveRate float64, seed uint32) *Bloom {
+	n = max(n, 1)
+	bits := math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	bytes := max(int(bits+7)/8, 8)
+	hashes := int(math.Round(float64(bytes*8) / float64(n) * math.Ln2))
+	return &Bloom{
+		Bits:   make([]byte, bytes),
+		Hashes: min(max(hashes, 1), maxBloomHashes),
+		Seed:   seed,
+	}
+}
+
+func (b *Bloom) Add(element int) {
+	h1, h2 := b.hashes(element)
+	m := uint64(len(b.Bits)) * 8
+	for i := range uint64(b.Hashes) {
+		bit := (h1 + i*h2) % m
+		b.Bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (b *Bloom) Contains(element int) bool {
+	h1, h2 := b.hashes(element)
+	m := uint64(len(b.Bits)) * 8
+	for i := range uint64(b.Hashes) {
+		bit := (h1 + i*h2) % m
+		if b.Bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hashes derives the two hashes combined into all probe positions.
+func (b *Bloom) hashes(element int) (uint64, uint64) {
+	h1 := hash(element ^ int(b.Seed)<<17)
+	h2 := hash(int(h1)) | 1
+	return h1, h2
+}
+
+// Validate checks a filter received from a peer before it is queried.
+func (b *Bloom) Validate() error {
+	if len(b.Bits) == 0 {
+		return errors.New("bloom filter has no bits")
+	}
+	if b.Hashes < 1 || b.Hashes > maxBloomHashes {
+		return errors.New("bloom filter hash count out of range")
+	}
+	return nil
+}
+
+// Missing returns the elements the filter's owner certainly lacks. Elements
+// hidden by a false positive are not returned; a later round with another
+// seed is likely to find them.
+func (b *Bloom) Missing(elements []int) []int {
+	var missing []int
+	for _, element := range elements {
+		if !b.Contains(element) {
+			missing = append(missing, element)
+		}
+	}
+	return missing
+}
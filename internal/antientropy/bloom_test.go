@@ -0,0 +1,125 @@
+package antientropy
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestBloom_NoFalseNegatives(t *testing.T) {
+	b := NewBloom(1000, 0.01, 1)
+	for i := range 1000 {
+		b.Add(i * 7)
+	}
+	for i := range 1000 {
+		if !b.Contains(i * 7) {
+			t.Fatalf("Contains(%d) = false for added element", i*7)
+		}
+	}
+}
+
+func TestBloom_FalsePositiveRate(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		rate float64
+	}{
+		{"1%", 1000, 0.01},
+		{"10%", 1000, 0.1},
+		{"tiny set", 3, 0.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBloom(tt.n, tt.rate, 42)
+			for i := range tt.n {
+				b.Add(i)
+			}
+			const probes = 20000
+			falsePositives := 0
+			for i := range probes {
+				if b.Contains(tt.n + i) {
+					falsePositives++
+				}
+			}
+			if got := float64(falsePositives) / probes; got > 2*tt.rate {
+				t.Errorf("false positive rate = %.4f, want about %.4f", got, tt.rate)
+			}
+		})
+	}
+}
+
+// Filters of the same set with different seeds miss different elements
+func TestBloom_Seed(t *testing.T) {
+	falsePositives := func(seed uint32) []int {
+		b := NewBloom(500, 0.05, seed)
+		for i := range 500 {
+			b.Add(i)
+		}
+		var fps []int
+		for i := 500; i < 5500; i++ {
+			if b.Contains(i) {
+				fps = append(fps, i)
+			}
+		}
+		return fps
+	}
+	a, b := falsePositives(1), falsePositives(2)
+	if len(a) == 0 || len(b) == 0 {
+		t.Fatalf("no false positives to compare: %d, %d", len(a), len(b))
+	}
+	shared := 0
+	for _, e := range a {
+		if slices.Contains(b, e) {
+			shared++
+		}
+	}
+	if shared > len(a)/2 {
+		t.Errorf("%d of %d false positives shared between seeds", shared, len(a))
+	}
+}
+
+func TestBloom_Missing(t *testing.T) {
+	b := NewBloom(100, 0.001, 3)
+	for _, e := range []int{1, 2, 3} {
+		b.Add(e)
+	}
+	if got := b.Missing([]int{1, 2, 3, 4, 5}); !slices.Equal(got, []int{4, 5}) {
+		t.Errorf("Missing() = %v, want [4 5]", got)
+	}
+}
+
+func TestBloom_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       Bloom
+		wantErr bool
+	}{
+		{"valid", *NewBloom(10, 0.01, 0), false},
+		{"no bits", Bloom{Hashes: 3}, true},
+		{"no hashes", Bloom{Bits: make([]byte, 8)}, true},
+		{"too many hashes", Bloom{Bits: make([]byte, 8), Hashes: maxBloomHashes + 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.b.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBloom_JSON(t *testing.T) {
+	b := NewBloom(100, 0.01, 9)
+	b.Add(5)
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got Bloom
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !got.Contains(5) || got.Seed != 9 || got.Hashes != b.Hashes {
+		t.Errorf("round trip = %+v, want %+v", got, b)
+	}
+}
@@ -0,0 +1,96 @@
+package antientropy
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+type messageCompare struct {
+	Type   string   `json:"type"`
+	Nodes  []int    `json:"nodes"`
+	Hashes []uint64 `json:"hashes"`
+}
+
+type messageCompareOk struct {
+	Differ []int `json:"differ"`
+}
+
+type messageExchange struct {
+	Type     string `json:"type"`
+	Leaves   []int  `json:"leaves"`
+	Elements []int  `json:"elements"`
+}
+
+type messageExchangeOk struct {
+	Elements []int `json:"elements"`
+}
+
+// Handle makes node answer the digest_compare and digest_exchange RPCs of
+// Remote peers from d. learn is handed the elements a peer sent that were new
+// to d, together with the peer.
+func Handle(node *maelstrom.Node, d *Digest, learn func(peer string, elements []int)) {
+	node.Handle("digest_compare", func(msg maelstrom.Message) error {
+		var body messageCompare
+		if err := json.Unmarshal(msg.Body, &body); err != nil {
+			return err
+		}
+		differ, err := d.Differing(body.Nodes, body.Hashes)
+		if err != nil {
+			return maelstrom.NewRPCError(maelstrom.MalformedRequest, err.Error())
+		}
+		return node.Reply(msg, map[string]any{"type": "digest_compare_ok", "differ": differ})
+	})
+	node.Handle("digest_exchange", func(msg maelstrom.Message) error {
+		var body messageExchange
+		if err := json.Unmarshal(msg.Body, &body); err != nil {
+			return err
+		}
+		ours, learned, err := d.Exchange(body.Leaves, body.Elements)
+		if err != nil {
+			return maelstrom.NewRPCError(maelstrom.MalformedRequest, err.Error())
+		}
+		if len(learned) > 0 {
+			learn(msg.Src, learned)
+		}
+		return node.Reply(msg, map[string]any{"type": "digest_exchange_ok", "elements": ours})
+	})
+}
+
+// Remote is a Peer reached over Maelstrom RPCs, which answers them with
+// Handle.
+type Remote struct {
+	node    *maelstrom.Node
+	peer    string
+	timeout time.Duration
+}
+
+// NewRemote returns peer as seen from node, giving up on an RPC after
+// timeout.
+func NewRemote(node *maelstrom.Node, peer string, timeout time.Duration) Remote {
+	return Remote{node: node, peer: peer, timeout: timeout}
+}
+
+func (r Remote) Compare(nodes []int, hashes []uint64) ([]int, error) {
+	var reply messageCompareOk
+	err := r.call(messageCompare{Type: "digest_compare", Nodes: nodes, Hashes: hashes}, &reply)
+	return reply.Differ, err
+}
+
+func (r Remote) Exchange(leaves []int, elements []int) ([]int, error) {
+	var reply messageExchangeOk
+	err := r.call(messageExchange{Type: "digest_exchange", Leaves: leaves, Elements: elements}, &reply)
+	return reply.Elements, err
+}
+
+func (r Remote) call(msg any, reply any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	resp, err := r.node.SyncRPC(ctx, r.peer, msg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.Body, reply)
+}
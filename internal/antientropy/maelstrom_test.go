@@ -0,0 +1,96 @@
+package antientropy
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// connect runs Maelstrom nodes with the given IDs, routing the messages they
+// send each other through pipes.
+func connect(t *testing.T, ids ...string) map[string]*maelstrom.Node {
+	t.Helper()
+	nodes := make(map[string]*maelstrom.Node, len(ids))
+	inputs := make(map[string]*io.PipeWriter, len(ids))
+	for _, id := range ids {
+		n := maelstrom.NewNode()
+		n.Init(id, ids)
+		in, inWriter := io.Pipe()
+		n.Stdin = in
+		nodes[id], inputs[id] = n, inWriter
+	}
+	for _, n := range nodes {
+		out, outWriter := io.Pipe()
+		n.Stdout = outWriter
+		t.Cleanup(func() { outWriter.Close() })
+		go func() {
+			scanner := bufio.NewScanner(out)
+			for scanner.Scan() {
+				var msg maelstrom.Message
+				if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+					continue
+				}
+				if input, ok := inputs[msg.Dest]; ok {
+					input.Write(append(scanner.Bytes(), '\n'))
+				}
+			}
+		}()
+	}
+	for id, n := range nodes {
+		t.Cleanup(func() { inputs[id].Close() })
+		go n.Run()
+	}
+	return nodes
+}
+
+func TestRemote_Sync(t *testing.T) {
+	nodes := connect(t, "n0", "n1")
+	local, remote := digestOf(rangeOf(0, 900)...), digestOf(rangeOf(100, 1000)...)
+
+	var mu sync.Mutex
+	var learnedFrom []string
+	var remoteLearned []int
+	Handle(nodes["n1"], remote, func(peer string, elements []int) {
+		mu.Lock()
+		defer mu.Unlock()
+		learnedFrom = append(learnedFrom, peer)
+		remoteLearned = append(remoteLearned, elements...)
+	})
+
+	learned, err := local.Sync(NewRemote(nodes["n0"], "n1", time.Second))
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	slices.Sort(learned)
+	if want := rangeOf(900, 1000); !slices.Equal(learned, want) {
+		t.Errorf("Sync() learned %v, want %v", learned, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	slices.Sort(remoteLearned)
+	if want := rangeOf(0, 100); !slices.Equal(remoteLearned, want) {
+		t.Errorf("peer learned %v, want %v", remoteLearned, want)
+	}
+	if !slices.Equal(learnedFrom, []string{"n0"}) {
+		t.Errorf("peer learned from %v, want [n0]", learnedFrom)
+	}
+	if got, want := allElements(remote), rangeOf(0, 1000); !slices.Equal(got, want) {
+		t.Errorf("remote elements = %v, want %v", got, want)
+	}
+}
+
+func TestRemote_Sync_Unreachable(t *testing.T) {
+	nodes := connect(t, "n0", "n1")
+	// n1 does not handle digest RPCs, so they time out
+	_, err := digestOf(1, 2, 3).Sync(NewRemote(nodes["n0"], "n1", 50*time.Millisecond))
+	if err == nil {
+		t.Error("Sync() error = nil, want an error")
+	}
+}
@@ -0,0 +1,160 @@
+// Package antientropy reconciles grow-only sets of ints between replicas. With
+// a Merkle Digest, replicas exchange hashes from the root down, descend only
+// into subtrees that differ and finally swap the elements of the differing
+// leaf buckets; the protocol is transport-agnostic, see Peer, and runs between
+// Maelstrom nodes with Handle and Remote. With a Bloom filter, a replica sends
+// a compact summary of its set in a single message and gets back the elements
+// the summary shows it lacks.
+package antientropy
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+const (
+	// fanout and depth give 16 internal nodes below the root and 256 leaf
+	// buckets, so a sync takes at most three round trips.
+	fanout = 16
+	depth  = 2
+
+	numLeaves = fanout * fanout
+	firstLeaf = (numLeaves - 1) / (fanout - 1)
+	numNodes  = firstLeaf + numLeaves
+
+	// hashMask keeps hashes below 2^63, so they survive JSON decoders that
+	// read numbers as signed 64-bit integers.
+	hashMask = 1<<63 - 1
+)
+
+// Digest is a Merkle tree over the elements of a set, laid out as a heap: the
+// root is node 0 and the children of node i are fanout*i+1 to fanout*i+fanout.
+// Each node's hash is the wrapping sum of the hashes of the elements below it,
+// so adding an element only touches the nodes on its path. Digest is safe for
+// concurrent use.
+type Digest struct {
+	mu      sync.Mutex
+	hashes  [numNodes]uint64
+	buckets [numLeaves]map[int]struct{}
+}
+
+func NewDigest() *Digest {
+	d := &Digest{}
+	for i := range d.buckets {
+		d.buckets[i] = make(map[int]struct{})
+	}
+	return d
+}
+
+// Add inserts elements and returns the ones that were not present yet.
+func (d *Digest) Add(elements ...int) []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var added []int
+	for _, element := range elements {
+		h := hash(element)
+		leaf := firstLeaf + int(h%numLeaves)
+		bucket := d.buckets[leaf-firstLeaf]
+		if _, ok := bucket[element]; ok {
+			continue
+		}
+		bucket[element] = struct{}{}
+		added = append(added, element)
+		for node := leaf; ; node = (node - 1) / fanout {
+			d.hashes[node] += h
+			if node == 0 {
+				break
+			}
+		}
+	}
+	return added
+}
+
+// Hashes returns the hashes of the given tree nodes.
+func (d *Digest) Hashes(nodes []int) ([]uint64, error) {
+	if err := checkNodes(nodes); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hashes := make([]uint64, len(nodes))
+	for i, node := range nodes {
+		hashes[i] = d.hashes[node] & hashMask
+	}
+	return hashes, nil
+}
+
+// Differing compares a peer's hashes of the given nodes with the local ones
+// and returns the nodes that differ.
+func (d *Digest) Differing(nodes []int, hashes []uint64) ([]int, error) {
+	if len(nodes) != len(hashes) {
+		return nil, fmt.Errorf("got %d hashes for %d nodes", len(hashes), len(nodes))
+	}
+	local, err := d.Hashes(nodes)
+	if err != nil {
+		return nil, err
+	}
+	var differ []int
+	for i, node := range nodes {
+		if local[i] != hashes[i] {
+			differ = append(differ, node)
+		}
+	}
+	return differ, nil
+}
+
+// Elements returns the elements in the given leaf buckets.
+func (d *Digest) Elements(leaves []int) ([]int, error) {
+	if err := checkNodes(leaves); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var elements []int
+	for _, leaf := range leaves {
+		if !isLeaf(leaf) {
+			return nil, fmt.Errorf("node %d is not a leaf", leaf)
+		}
+		for element := range d.buckets[leaf-firstLeaf] {
+			elements = append(elements, element)
+		}
+	}
+	return elements, nil
+}
+
+func isLeaf(node int) bool {
+	return node >= firstLeaf
+}
+
+func children(nodes []int) []int {
+	var next []int
+	for _, node := range nodes {
+		for i := 1; i <= fanout; i++ {
+			next = append(next, fanout*node+i)
+		}
+	}
+	return next
+}
+
+func checkNodes(nodes []int) error {
+	if i := slices.IndexFunc(nodes, func(node int) bool { return node < 0 || node >= numNodes }); i != -1 {
+		return fmt.Errorf("unknown digest node %d", nodes[i])
+	}
+	return nil
+}
+
+// hash spreads element over 64 bits (a splitmix64 step), so both the bucket
+// and the sums are well distributed for dense ranges of ints.
+func hash(element int) uint64 {
+	h := uint64(element) + 0x9e3779b97f4a7c15
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
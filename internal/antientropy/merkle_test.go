@@ -0,0 +1,196 @@
+package antientropy
+
+import (
+	"slices"
+	"testing"
+)
+
+// memoryPeer answers a sync from another in-process digest and counts the
+// traffic it took.
+type memoryPeer struct {
+	d         *Digest
+	learned   []int
+	compares  int
+	exchanges int
+	sent      int
+}
+
+func (p *memoryPeer) Compare(nodes []int, hashes []uint64) ([]int, error) {
+	p.compares++
+	return p.d.Differing(nodes, hashes)
+}
+
+func (p *memoryPeer) Exchange(leaves []int, elements []int) ([]int, error) {
+	p.exchanges++
+	p.sent += len(elements)
+	ours, learned, err := p.d.Exchange(leaves, elements)
+	p.learned = append(p.learned, learned...)
+	return ours, err
+}
+
+func digestOf(elements ...int) *Digest {
+	d := NewDigest()
+	d.Add(elements...)
+	return d
+}
+
+func allElements(d *Digest) []int {
+	leaves := make([]int, numLeaves)
+	for i := range leaves {
+		leaves[i] = firstLeaf + i
+	}
+	elements, _ := d.Elements(leaves)
+	slices.Sort(elements)
+	return elements
+}
+
+func rangeOf(lo, hi int) []int {
+	var elements []int
+	for i := lo; i < hi; i++ {
+		elements = append(elements, i)
+	}
+	return elements
+}
+
+func TestDigest_Add(t *testing.T) {
+	d := NewDigest()
+	if got := d.Add(1, 2, 3); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("Add() = %v, want [1 2 3]", got)
+	}
+	if got := d.Add(2, 3, 4); !slices.Equal(got, []int{4}) {
+		t.Errorf("Add() = %v, want [4]", got)
+	}
+	if got := allElements(d); !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Errorf("elements = %v, want [1 2 3 4]", got)
+	}
+}
+
+// The digest depends on the elements only, not on the order they were added in
+func TestDigest_Hashes_OrderIndependent(t *testing.T) {
+	a := digestOf(1, 2, 3, 100, -7)
+	b := digestOf(-7, 100, 3, 2, 1, 2)
+	nodes := rangeOf(0, numNodes)
+	if differ, _ := a.Differing(nodes, must(b.Hashes(nodes))); len(differ) != 0 {
+		t.Errorf("Differing() = %v, want none", differ)
+	}
+	if root := must(a.Hashes([]int{0}))[0]; root == must(NewDigest().Hashes([]int{0}))[0] {
+		t.Errorf("root hash of non-empty digest equals the empty one")
+	}
+}
+
+func TestDigest_InvalidNodes(t *testing.T) {
+	d := NewDigest()
+	if _, err := d.Hashes([]int{numNodes}); err == nil {
+		t.Errorf("Hashes() of unknown node error = nil")
+	}
+	if _, err := d.Differing([]int{0, 1}, []uint64{0}); err == nil {
+		t.Errorf("Differing() with missing hashes error = nil")
+	}
+	if _, err := d.Elements([]int{0}); err == nil {
+		t.Errorf("Elements() of internal node error = nil")
+	}
+}
+
+func TestDigest_Sync(t *testing.T) {
+	tests := []struct {
+		name          string
+		local, remote []int
+		wantCompares  int
+		wantExchanges int
+	}{
+		{
+			name:         "both empty",
+			wantCompares: 1,
+		},
+		{
+			name:         "in sync",
+			local:        rangeOf(0, 1000),
+			remote:       rangeOf(0, 1000),
+			wantCompares: 1,
+		},
+		{
+			name:          "local missing one element",
+			local:         rangeOf(0, 999),
+			remote:        rangeOf(0, 1000),
+			wantCompares:  3,
+			wantExchanges: 1,
+		},
+		{
+			name:          "disjoint",
+			local:         rangeOf(0, 500),
+			remote:        rangeOf(500, 1000),
+			wantCompares:  3,
+			wantExchanges: 1,
+		},
+		{
+			name:          "remote empty",
+			local:         rangeOf(0, 10),
+			wantCompares:  3,
+			wantExchanges: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := digestOf(tt.local...)
+			peer := &memoryPeer{d: digestOf(tt.remote...)}
+
+			learned, err := local.Sync(peer)
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			if peer.compares != tt.wantCompares || peer.exchanges != tt.wantExchanges {
+				t.Errorf("Sync() took %d compares, %d exchanges, want %d, %d",
+					peer.compares, peer.exchanges, tt.wantCompares, tt.wantExchanges)
+			}
+
+			want := append(slices.Clone(tt.local), tt.remote...)
+			slices.Sort(want)
+			want = slices.Compact(want)
+			if got := allElements(local); !slices.Equal(got, want) {
+				t.Errorf("local elements = %v, want %v", got, want)
+			}
+			if got := allElements(peer.d); !slices.Equal(got, want) {
+				t.Errorf("remote elements = %v, want %v", got, want)
+			}
+
+			slices.Sort(learned)
+			if wantLearned := without(tt.remote, tt.local); !slices.Equal(learned, wantLearned) {
+				t.Errorf("Sync() learned %v, want %v", learned, wantLearned)
+			}
+		})
+	}
+}
+
+// Only the buckets that differ are transferred
+func TestDigest_Sync_TransfersDifferingBuckets(t *testing.T) {
+	local := digestOf(rangeOf(0, 10000)...)
+	peer := &memoryPeer{d: digestOf(rangeOf(0, 10001)...)}
+	if _, err := local.Sync(peer); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	// One bucket of ~10000/256 elements, not the whole set
+	if peer.sent > 200 {
+		t.Errorf("Sync() sent %d elements for a single missing one", peer.sent)
+	}
+	if !slices.Equal(peer.learned, nil) {
+		t.Errorf("peer learned %v, want nothing", peer.learned)
+	}
+}
+
+func without(elements, remove []int) []int {
+	var out []int
+	for _, e := range elements {
+		if !slices.Contains(remove, e) {
+			out = append(out, e)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
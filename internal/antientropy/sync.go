@@ -0,0 +1,57 @@
+package antientropy
+
+// Peer is the remote end of a sync, e.g. a Remote Maelstrom node, which
+// answers with Digest.Differing and Digest.Exchange.
+type Peer interface {
+	// Compare sends the hashes of the given tree nodes and returns the nodes
+	// whose hashes differ on the peer.
+	Compare(nodes []int, hashes []uint64) ([]int, error)
+	// Exchange sends the local elements of the given leaf buckets and returns
+	// the peer's elements of the same buckets.
+	Exchange(leaves []int, elements []int) ([]int, error)
+}
+
+// Sync reconciles d with peer, level by level from the root, and returns the
+// elements learned from it. The peer learns the elements it was missing from
+// the same exchange.
+func (d *Digest) Sync(peer Peer) ([]int, error) {
+	nodes := []int{0}
+	for {
+		hashes, err := d.Hashes(nodes)
+		if err != nil {
+			return nil, err
+		}
+		differ, err := peer.Compare(nodes, hashes)
+		if err != nil {
+			return nil, err
+		}
+		if len(differ) == 0 {
+			return nil, nil
+		}
+		if isLeaf(differ[0]) {
+			nodes = differ
+			break
+		}
+		nodes = children(differ)
+	}
+
+	elements, err := d.Elements(nodes)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := peer.Exchange(nodes, elements)
+	if err != nil {
+		return nil, err
+	}
+	return d.Add(theirs...), nil
+}
+
+// Exchange answers a peer's Exchange: it returns the local elements of the
+// given leaf buckets and the peer's elements that were new locally.
+func (d *Digest) Exchange(leaves []int, elements []int) (ours []int, learned []int, err error) {
+	ours, err = d.Elements(leaves)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ours, d.Add(elements...), nil
+}
@@ -0,0 +1,154 @@
+package crdt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+)
+
+var ErrInsufficientRights = errors.New("insufficient rights")
+
+// BoundedCounter is a counter that never drops below zero without
+// coordinating on every decrement. Each increment grants its node the right to
+// decrement by the same amount; a node may only spend rights it holds and can
+// transfer spare rights to a node that ran out.
+type BoundedCounter struct {
+	// Grants[from][to] sums the rights from transferred to to; Grants[n][n]
+	// sums the increments of n.
+	Grants map[string]GCounter[string, int] `json:"grants"`
+	// Used sums the decrements of each node.
+	Used GCounter[string, int] `json:"used"`
+}
+
+func NewBoundedCounter() BoundedCounter {
+	return BoundedCounter{
+		Grants: make(map[string]GCounter[string, int]),
+		Used:   make(GCounter[string, int]),
+	}
+}
+
+func (c BoundedCounter) grant(from, to string, amount int) BoundedCounter {
+	if c.Grants[from] == nil {
+		c.Grants[from] = make(GCounter[string, int])
+	}
+	d := NewBoundedCounter()
+	d.Grants[from] = c.Grants[from].Increment(to, amount)
+	return d
+}
+
+// Increment adds amount to the counter and to the rights of node, returning
+// the delta-state.
+func (c BoundedCounter) Increment(node string, amount int) (BoundedCounter, error) {
+	if amount < 0 {
+		return BoundedCounter{}, fmt.Errorf("negative increment %d", amount)
+	}
+	return c.grant(node, node, amount), nil
+}
+
+// Decrement subtracts amount from the counter, spending rights of node. It
+// fails with ErrInsufficientRights instead of letting the value go negative.
+func (c BoundedCounter) Decrement(node string, amount int) (BoundedCounter, error) {
+	if amount < 0 {
+		return BoundedCounter{}, fmt.Errorf("negative decrement %d", amount)
+	}
+	if rights := c.Rights(node); amount > rights {
+		return BoundedCounter{}, fmt.Errorf("%w: %s holds %d, needs %d", ErrInsufficientRights, node, rights, amount)
+	}
+	d := NewBoundedCounter()
+	d.Used = c.Used.Increment(node, amount)
+	return d, nil
+}
+
+// Transfer moves amount of the rights held by from to to, returning the
+// delta-state. Only from may issue it.
+func (c BoundedCounter) Transfer(from, to string, amount int) (BoundedCounter, error) {
+	if amount < 0 {
+		return BoundedCounter{}, fmt.Errorf("negative transfer %d", amount)
+	}
+	if from == to {
+		return NewBoundedCounter(), nil
+	}
+	if rights := c.Rights(from); amount > rights {
+		return BoundedCounter{}, fmt.Errorf("%w: %s holds %d, transfers %d", ErrInsufficientRights, from, rights, amount)
+	}
+	return c.grant(from, to, amount), nil
+}
+
+// Rights returns how much node may still decrement on its own.
+func (c BoundedCounter) Rights(node string) int {
+	rights := -c.Used[node]
+	for from, granted := range c.Grants {
+		for to, amount := range granted {
+			if from == to {
+				if from == node {
+					rights += amount
+				}
+				continue
+			}
+			if to == node {
+				rights += amount
+			}
+			if from == node {
+				rights -= amount
+			}
+		}
+	}
+	return rights
+}
+
+func (c BoundedCounter) Value() int {
+	value := -c.Used.Value()
+	for node, granted := range c.Grants {
+		value += granted[node]
+	}
+	return value
+}
+
+func (c BoundedCounter) Merge(other BoundedCounter) {
+	for from, granted := range other.Grants {
+		if c.Grants[from] == nil {
+			c.Grants[from] = make(GCounter[string, int])
+		}
+		c.Grants[from].Merge(granted)
+	}
+	c.Used.Merge(other.Used)
+}
+
+func (c BoundedCounter) Copy() BoundedCounter {
+	cpy := NewBoundedCounter()
+	for from, granted := range c.Grants {
+		cpy.Grants[from] = granted.Copy()
+	}
+	maps.Copy(cpy.Used, c.Used)
+	return cpy
+}
+
+func (c BoundedCounter) Equal(other BoundedCounter) bool {
+	for from := range c.Grants {
+		if !c.Grants[from].Equal(other.Grants[from]) {
+			return false
+		}
+	}
+	for from := range other.Grants {
+		if !c.Grants[from].Equal(other.Grants[from]) {
+			return false
+		}
+	}
+	return c.Used.Equal(other.Used)
+}
+
+func (c BoundedCounter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(boundedCounterJSON(c))
+}
+
+func (c *BoundedCounter) UnmarshalJSON(data []byte) error {
+	fields := boundedCounterJSON(NewBoundedCounter())
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*c = BoundedCounter(fields)
+	return nil
+}
+
+type boundedCounterJSON BoundedCounter
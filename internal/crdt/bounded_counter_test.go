@@ -0,0 +1,196 @@
+package crdt
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+
+	"gossip-glomers/internal/crdt/crdttest"
+)
+
+type boundedOp struct {
+	kind   string
+	node   string
+	to     string
+	amount int
+}
+
+func applyBoundedOps(c BoundedCounter, ops []boundedOp) error {
+	for _, op := range ops {
+		var err error
+		switch op.kind {
+		case "inc":
+			_, err = c.Increment(op.node, op.amount)
+		case "dec":
+			_, err = c.Decrement(op.node, op.amount)
+		case "transfer":
+			_, err = c.Transfer(op.node, op.to, op.amount)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestBoundedCounter_Ops(t *testing.T) {
+	tests := []struct {
+		name       string
+		ops        []boundedOp
+		wantValue  int
+		wantRights map[string]int
+		wantErr    error
+	}{
+		{
+			name:       "increment grants rights",
+			ops:        []boundedOp{{"inc", "n0", "", 5}},
+			wantValue:  5,
+			wantRights: map[string]int{"n0": 5, "n1": 0},
+		},
+		{
+			name:       "decrement within rights",
+			ops:        []boundedOp{{"inc", "n0", "", 5}, {"dec", "n0", "", 3}},
+			wantValue:  2,
+			wantRights: map[string]int{"n0": 2},
+		},
+		{
+			name:       "decrement to exactly zero",
+			ops:        []boundedOp{{"inc", "n0", "", 5}, {"dec", "n0", "", 5}},
+			wantValue:  0,
+			wantRights: map[string]int{"n0": 0},
+		},
+		{
+			name:       "decrement below zero is rejected",
+			ops:        []boundedOp{{"inc", "n0", "", 3}, {"dec", "n0", "", 5}},
+			wantValue:  3,
+			wantRights: map[string]int{"n0": 3},
+			wantErr:    ErrInsufficientRights,
+		},
+		{
+			name:       "decrement with rights held by another node is rejected",
+			ops:        []boundedOp{{"inc", "n0", "", 3}, {"dec", "n1", "", 1}},
+			wantValue:  3,
+			wantRights: map[string]int{"n0": 3, "n1": 0},
+			wantErr:    ErrInsufficientRights,
+		},
+		{
+			name:       "transfer moves rights, not value",
+			ops:        []boundedOp{{"inc", "n0", "", 5}, {"transfer", "n0", "n1", 2}},
+			wantValue:  5,
+			wantRights: map[string]int{"n0": 3, "n1": 2},
+		},
+		{
+			name:       "transferred rights can be spent",
+			ops:        []boundedOp{{"inc", "n0", "", 5}, {"transfer", "n0", "n1", 2}, {"dec", "n1", "", 2}},
+			wantValue:  3,
+			wantRights: map[string]int{"n0": 3, "n1": 0},
+		},
+		{
+			name:       "transfer beyond rights is rejected",
+			ops:        []boundedOp{{"inc", "n0", "", 1}, {"transfer", "n0", "n1", 2}},
+			wantValue:  1,
+			wantRights: map[string]int{"n0": 1, "n1": 0},
+			wantErr:    ErrInsufficientRights,
+		},
+		{
+			name:       "transfer to self is a no-op",
+			ops:        []boundedOp{{"inc", "n0", "", 1}, {"transfer", "n0", "n0", 1}},
+			wantValue:  1,
+			wantRights: map[string]int{"n0": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewBoundedCounter()
+			err := applyBoundedOps(c, tt.ops)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got := c.Value(); got != tt.wantValue {
+				t.Errorf("Value() = %d, want %d", got, tt.wantValue)
+			}
+			for node, want := range tt.wantRights {
+				if got := c.Rights(node); got != want {
+					t.Errorf("Rights(%s) = %d, want %d", node, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBoundedCounter_NegativeAmounts(t *testing.T) {
+	c := NewBoundedCounter()
+	c.Increment("n0", 5)
+	if _, err := c.Increment("n0", -1); err == nil {
+		t.Errorf("Increment(-1) error = nil")
+	}
+	if _, err := c.Decrement("n0", -1); err == nil {
+		t.Errorf("Decrement(-1) error = nil")
+	}
+	if _, err := c.Transfer("n0", "n1", -1); err == nil {
+		t.Errorf("Transfer(-1) error = nil")
+	}
+	if got := c.Value(); got != 5 {
+		t.Errorf("Value() = %d, want 5", got)
+	}
+}
+
+// Deltas carry the touched entries, so replicas catch up without the full state
+func TestBoundedCounter_Deltas(t *testing.T) {
+	local := NewBoundedCounter()
+	remote := NewBoundedCounter()
+
+	d, _ := local.Increment("n0", 5)
+	remote.Merge(d)
+	d, _ = local.Transfer("n0", "n1", 2)
+	remote.Merge(d)
+	d, _ = local.Decrement("n0", 1)
+	remote.Merge(d)
+
+	if !remote.Equal(local) {
+		t.Errorf("remote = %+v, want %+v", remote, local)
+	}
+}
+
+// Concurrent decrements can never take the value below zero, since every node
+// only spends rights it holds
+func TestBoundedCounter_Merge_NeverNegative(t *testing.T) {
+	base := NewBoundedCounter()
+	base.Increment("n0", 4)
+	base.Transfer("n0", "n1", 2)
+
+	a := base.Copy()
+	b := base.Copy()
+	if _, err := a.Decrement("n0", 2); err != nil {
+		t.Fatalf("Decrement() error = %v", err)
+	}
+	if _, err := b.Decrement("n1", 2); err != nil {
+		t.Fatalf("Decrement() error = %v", err)
+	}
+	if _, err := b.Decrement("n1", 1); !errors.Is(err, ErrInsufficientRights) {
+		t.Fatalf("Decrement() error = %v, want %v", err, ErrInsufficientRights)
+	}
+
+	a.Merge(b)
+	if got := a.Value(); got != 0 {
+		t.Errorf("Value() after Merge = %d, want 0", got)
+	}
+}
+
+func TestBoundedCounter_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[BoundedCounter]{
+		New: NewBoundedCounter,
+		Op: func(r *rand.Rand, node string, c BoundedCounter) {
+			switch r.IntN(3) {
+			case 0:
+				c.Increment(node, r.IntN(5))
+			case 1:
+				c.Decrement(node, r.IntN(5))
+			case 2:
+				c.Transfer(node, "n"+strconv.Itoa(r.IntN(3)), r.IntN(3))
+			}
+		},
+		Equal: BoundedCounter.Equal,
+	})
+}
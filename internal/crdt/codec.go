@@ -0,0 +1,398 @@
+package crdt
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// binaryVersion prefixes every binary encoding so the format can evolve.
+const binaryVersion = 1
+
+var ErrMalformedBinary = errors.New("malformed binary encoding")
+
+// The binary encodings are built from varints: a GCounter is its entry count
+// followed by key/value pairs sorted by key, a PNCounter is its positive and
+// negative counter, a GSet of integers is its element count followed by the
+// sorted elements, each stored as the difference to its predecessor, and a
+// RangeSet is its interval count followed by every interval as the difference
+// of its start to the end of its predecessor and its length.
+// Keys and elements may be strings or any built-in integer type.
+
+func (c GCounter[K, V]) MarshalBinary() ([]byte, error) {
+	return c.appendBinary([]byte{binaryVersion})
+}
+
+func (c *GCounter[K, V]) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data)
+	if err != nil {
+		return err
+	}
+	m := readGCounter[K, V](r)
+	if err := r.close(); err != nil {
+		return err
+	}
+	*c = m
+	return nil
+}
+
+func (c GCounter[K, V]) appendBinary(buf []byte) ([]byte, error) {
+	type entry struct {
+		key []byte
+		val V
+	}
+	entries := make([]entry, 0, len(c))
+	for key, val := range c {
+		enc, err := appendScalar(nil, key)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{enc, val})
+	}
+	slices.SortFunc(entries, func(a, b entry) int { return bytes.Compare(a.key, b.key) })
+
+	buf = binary.AppendUvarint(buf, uint64(len(entries)))
+	for _, e := range entries {
+		buf = append(buf, e.key...)
+		buf = binary.AppendVarint(buf, int64(e.val))
+	}
+	return buf, nil
+}
+
+func readGCounter[K comparable, V Integer](r *binaryReader) GCounter[K, V] {
+	n := r.count()
+	c := make(GCounter[K, V], n)
+	for range n {
+		key := readScalar[K](r)
+		c[key] = V(r.varint())
+	}
+	return c
+}
+
+func (c PNCounter[K, V]) MarshalBinary() ([]byte, error) {
+	buf, err := c.Positive.appendBinary([]byte{binaryVersion})
+	if err != nil {
+		return nil, err
+	}
+	return c.Negative.appendBinary(buf)
+}
+
+func (c *PNCounter[K, V]) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data)
+	if err != nil {
+		return err
+	}
+	positive := readGCounter[K, V](r)
+	negative := readGCounter[K, V](r)
+	if err := r.close(); err != nil {
+		return err
+	}
+	*c = PNCounter[K, V]{Positive: positive, Negative: negative}
+	return nil
+}
+
+func (c GSet[T]) MarshalBinary() ([]byte, error) {
+	buf := binary.AppendUvarint([]byte{binaryVersion}, uint64(len(c)))
+	if _, ok := any(*new(T)).(string); ok {
+		elements := c.Elements()
+		slices.SortFunc(elements, func(a, b T) int { return cmp.Compare(any(a).(string), any(b).(string)) })
+		for _, element := range elements {
+			buf, _ = appendScalar(buf, element)
+		}
+		return buf, nil
+	}
+
+	ints := make([]int64, 0, len(c))
+	for element := range c {
+		n, ok := scalarInt(element)
+		if !ok {
+			return nil, fmt.Errorf("binary encoding of %T elements is not supported", element)
+		}
+		ints = append(ints, n)
+	}
+	slices.Sort(ints)
+	var prev uint64
+	for _, n := range ints {
+		// The wrapping difference round-trips even when it overflows int64
+		buf = binary.AppendUvarint(buf, uint64(n)-prev)
+		prev = uint64(n)
+	}
+	return buf, nil
+}
+
+func (c *GSet[T]) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data)
+	if err != nil {
+		return err
+	}
+	n := r.count()
+	set := make(GSet[T], n)
+	if _, ok := any(*new(T)).(string); ok {
+		for range n {
+			set[readScalar[T](r)] = struct{}{}
+		}
+	} else {
+		var prev uint64
+		for range n {
+			prev += r.uvarint()
+			element, ok := intScalar[T](int64(prev))
+			if !ok {
+				return fmt.Errorf("binary decoding of %T elements is not supported", element)
+			}
+			set[element] = struct{}{}
+		}
+	}
+	if err := r.close(); err != nil {
+		return err
+	}
+	*c = set
+	return nil
+}
+
+func (s *RangeSet[T]) MarshalBinary() ([]byte, error) {
+	buf := binary.AppendUvarint([]byte{binaryVersion}, uint64(len(s.ranges)))
+	var prev uint64
+	for _, r := range s.ranges {
+		// The wrapping differences round-trip even when they overflow T
+		buf = binary.AppendUvarint(buf, uint64(r[0])-prev)
+		buf = binary.AppendUvarint(buf, uint64(r[1]-r[0]))
+		prev = uint64(r[1])
+	}
+	return buf, nil
+}
+
+func (s *RangeSet[T]) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data)
+	if err != nil {
+		return err
+	}
+	n := r.count()
+	ranges := make([][2]T, 0, n)
+	var prev uint64
+	for range n {
+		lo := prev + r.uvarint()
+		prev = lo + r.uvarint()
+		ranges = append(ranges, [2]T{T(lo), T(prev)})
+	}
+	if err := r.close(); err != nil {
+		return err
+	}
+	return s.setRanges(ranges)
+}
+
+// appendScalar encodes a string as its length and bytes, an integer as a varint.
+func appendScalar[T comparable](buf []byte, v T) ([]byte, error) {
+	if s, ok := any(v).(string); ok {
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		return append(buf, s...), nil
+	}
+	n, ok := scalarInt(v)
+	if !ok {
+		return nil, fmt.Errorf("binary encoding of %T is not supported", v)
+	}
+	return binary.AppendVarint(buf, n), nil
+}
+
+func readScalar[T comparable](r *binaryReader) T {
+	var v T
+	if p, ok := any(&v).(*string); ok {
+		*p = string(r.bytes(r.count()))
+		return v
+	}
+	v, ok := intScalar[T](r.varint())
+	if !ok {
+		r.fail(fmt.Errorf("binary decoding of %T is not supported", v))
+	}
+	return v
+}
+
+func scalarInt[T comparable](v T) (int64, bool) {
+	switch v := any(v).(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func intScalar[T comparable](n int64) (T, bool) {
+	var v T
+	switch p := any(&v).(type) {
+	case *int:
+		*p = int(n)
+	case *int8:
+		*p = int8(n)
+	case *int16:
+		*p = int16(n)
+	case *int32:
+		*p = int32(n)
+	case *int64:
+		*p = n
+	case *uint:
+		*p = uint(n)
+	case *uint8:
+		*p = uint8(n)
+	case *uint16:
+		*p = uint16(n)
+	case *uint32:
+		*p = uint32(n)
+	case *uint64:
+		*p = uint64(n)
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+// binaryReader consumes varints from a buffer and remembers the first error,
+// so decoders can read a whole structure and check once at the end.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func newBinaryReader(data []byte) (*binaryReader, error) {
+	if len(data) == 0 || data[0] != binaryVersion {
+		return nil, fmt.Errorf("%w: unknown version", ErrMalformedBinary)
+	}
+	return &binaryReader{data: data[1:]}, nil
+}
+
+func (r *binaryReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+	r.data = nil
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail(fmt.Errorf("%w: bad varint", ErrMalformedBinary))
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) varint() int64 {
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail(fmt.Errorf("%w: bad varint", ErrMalformedBinary))
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+// count reads a length; every counted item takes at least one byte, so a
+// length beyond the remaining input is rejected before anything is allocated.
+func (r *binaryReader) count() int {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.fail(fmt.Errorf("%w: length %d exceeds input", ErrMalformedBinary, n))
+		return 0
+	}
+	return int(n)
+}
+
+func (r *binaryReader) bytes(n int) []byte {
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *binaryReader) close() error {
+	if r.err == nil && len(r.data) > 0 {
+		r.err = fmt.Errorf("%w: %d trailing bytes", ErrMalformedBinary, len(r.data))
+	}
+	return r.err
+}
+
+// Encodings of CRDTs in gossip messages, as selected with ParseEncoding.
+const (
+	EncodingJSON   = "json"
+	EncodingBinary = "binary"
+)
+
+var ErrUnknownEncoding = errors.New("unknown encoding")
+
+// ParseEncoding reports whether name selects the binary encoding, which nodes
+// pass on as Compact.Binary.
+func ParseEncoding(name string) (bool, error) {
+	switch name {
+	case EncodingJSON:
+		return false, nil
+	case EncodingBinary:
+		return true, nil
+	}
+	return false, fmt.Errorf("%w %q", ErrUnknownEncoding, name)
+}
+
+// Compact wraps a CRDT in gossip messages. With Binary set it is encoded in
+// JSON as a base64 string of its binary form, which keeps it valid inside a
+// Maelstrom message body; otherwise it is encoded as plain JSON, which every
+// node understands. Binary must only be set once all nodes decode Compact.
+// Decoding accepts both encodings.
+type Compact[T encoding.BinaryMarshaler] struct {
+	Value  T
+	Binary bool
+}
+
+func (c Compact[T]) MarshalJSON() ([]byte, error) {
+	if !c.Binary {
+		return json.Marshal(c.Value)
+	}
+	data, err := c.Value.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(base64.StdEncoding.EncodeToString(data))
+}
+
+func (c *Compact[T]) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return json.Unmarshal(data, &c.Value)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	// Pointer types like *RangeSet decode into a fresh value, as with JSON
+	if v := reflect.ValueOf(&c.Value).Elem(); v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		if u, ok := any(c.Value).(encoding.BinaryUnmarshaler); ok {
+			return u.UnmarshalBinary(raw)
+		}
+	}
+	u, ok := any(&c.Value).(encoding.BinaryUnmarshaler)
+	if !ok {
+		return fmt.Errorf("%T does not implement encoding.BinaryUnmarshaler", c.Value)
+	}
+	return u.UnmarshalBinary(raw)
+}
@@ -0,0 +1,333 @@
+package crdt
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestGCounter_Binary(t *testing.T) {
+	tests := []struct {
+		name string
+		c    GCounter[string, int]
+	}{
+		{name: "empty", c: GCounter[string, int]{}},
+		{name: "single entry", c: GCounter[string, int]{"n0": 5}},
+		{name: "several entries", c: GCounter[string, int]{"n0": 5, "n1": 0, "n2": 1 << 40}},
+		{name: "negative value", c: GCounter[string, int]{"n0": -3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.c.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			var got GCounter[string, int]
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+			if !got.Equal(tt.c) {
+				t.Errorf("round trip = %v, want %v", got, tt.c)
+			}
+		})
+	}
+}
+
+func TestGCounter_Binary_IntegerKeys(t *testing.T) {
+	c := GCounter[uint64, uint64]{0: math.MaxUint64, math.MaxUint64: 1}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	var got GCounter[uint64, uint64]
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if !got.Equal(c) {
+		t.Errorf("round trip = %v, want %v", got, c)
+	}
+}
+
+func TestPNCounter_Binary(t *testing.T) {
+	c := NewPNCounter[string, int]()
+	c.Increment("n0", 7)
+	c.Increment("n1", -4)
+
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	var got PNCounter[string, int]
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if !got.Equal(c) {
+		t.Errorf("round trip = %v, want %v", got, c)
+	}
+}
+
+func TestGSet_Binary(t *testing.T) {
+	tests := []struct {
+		name string
+		c    GSet[int]
+	}{
+		{name: "empty", c: GSet[int]{}},
+		{name: "dense", c: GSet[int]{1: {}, 2: {}, 3: {}, 4: {}}},
+		{name: "negative", c: GSet[int]{-5: {}, 0: {}, 5: {}}},
+		{name: "extremes", c: GSet[int]{math.MinInt: {}, math.MaxInt: {}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.c.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			var got GSet[int]
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+			if !got.Equal(tt.c) {
+				t.Errorf("round trip = %v, want %v", got, tt.c)
+			}
+		})
+	}
+}
+
+func TestGSet_Binary_Strings(t *testing.T) {
+	c := GSet[string]{"": {}, "a": {}, "bc": {}}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	var got GSet[string]
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if !got.Equal(c) {
+		t.Errorf("round trip = %v, want %v", got, c)
+	}
+}
+
+func TestGSet_Binary_Unsupported(t *testing.T) {
+	c := GSet[float64]{1.5: {}}
+	if _, err := c.MarshalBinary(); err == nil {
+		t.Errorf("MarshalBinary() error = nil for float elements")
+	}
+}
+
+func TestRangeSet_Binary(t *testing.T) {
+	tests := []struct {
+		name string
+		s    *RangeSet[int]
+	}{
+		{name: "empty", s: NewRangeSet[int]()},
+		{name: "runs", s: NewRangeSet(1, 2, 3, 7, 9, 10)},
+		{name: "negative", s: NewRangeSet(-5, -4, 0, 5)},
+		{name: "extremes", s: NewRangeSet(math.MinInt, math.MaxInt-1, math.MaxInt)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.s.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			got := NewRangeSet[int]()
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+			if !got.Equal(tt.s) {
+				t.Errorf("round trip = %v, want %v", got.Ranges(), tt.s.Ranges())
+			}
+		})
+	}
+}
+
+func TestUnmarshalBinary_Malformed(t *testing.T) {
+	valid, _ := GCounter[string, int]{"n0": 1}.MarshalBinary()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "unknown version", data: []byte{2, 0}},
+		{name: "truncated", data: valid[:len(valid)-1]},
+		{name: "trailing bytes", data: append(valid, 0)},
+		{name: "length beyond input", data: []byte{binaryVersion, 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got GCounter[string, int]
+			if err := got.UnmarshalBinary(tt.data); !errors.Is(err, ErrMalformedBinary) {
+				t.Errorf("UnmarshalBinary() error = %v, want %v", err, ErrMalformedBinary)
+			}
+		})
+	}
+}
+
+func TestCompact_JSON(t *testing.T) {
+	c := Compact[GSet[int]]{Value: GSet[int]{1: {}, 2: {}, 3: {}}, Binary: true}
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if data[0] != '"' {
+		t.Errorf("Marshal() = %s, want a base64 string", data)
+	}
+
+	var got Compact[GSet[int]]
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !got.Value.Equal(c.Value) {
+		t.Errorf("round trip = %v, want %v", got.Value, c.Value)
+	}
+}
+
+func TestCompact_JSON_Pointer(t *testing.T) {
+	c := Compact[*RangeSet[int]]{Value: NewRangeSet(1, 2, 3, 9), Binary: true}
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got Compact[*RangeSet[int]]
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !got.Value.Equal(c.Value) {
+		t.Errorf("round trip = %v, want %v", got.Value.Ranges(), c.Value.Ranges())
+	}
+}
+
+// Without Binary the plain JSON encoding is sent, for nodes that do not
+// decode Compact yet
+func TestCompact_JSON_Default(t *testing.T) {
+	c := Compact[PNCounter[string, int]]{Value: NewPNCounter[string, int]()}
+	c.Value.Increment("n0", 3)
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	plain, _ := json.Marshal(c.Value)
+	if string(data) != string(plain) {
+		t.Errorf("Marshal() = %s, want %s", data, plain)
+	}
+
+	var got Compact[PNCounter[string, int]]
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if v := got.Value.Value(); v != 3 {
+		t.Errorf("Value() = %d, want 3", v)
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    bool
+		wantErr error
+	}{
+		{EncodingJSON, false, nil},
+		{EncodingBinary, true, nil},
+		{"msgpack", false, ErrUnknownEncoding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEncoding(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseEncoding() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseEncoding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// The plain JSON encoding is still accepted
+func TestCompact_JSON_Legacy(t *testing.T) {
+	var got Compact[PNCounter[string, int]]
+	if err := json.Unmarshal([]byte(`{"Positive":{"n0":3},"Negative":{"n1":1}}`), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if v := got.Value.Value(); v != 2 {
+		t.Errorf("Value() = %d, want 2", v)
+	}
+}
+
+func TestCompact_SmallerThanJSON(t *testing.T) {
+	for _, size := range []int{10, 1000} {
+		set := benchGSet(size)
+		plain, _ := json.Marshal(set)
+		compact, _ := json.Marshal(Compact[GSet[int]]{Value: set, Binary: true})
+		if len(compact) >= len(plain) {
+			t.Errorf("GSet of %d: compact %d bytes, plain JSON %d bytes", size, len(compact), len(plain))
+		}
+
+		counter := benchPNCounter(size)
+		plain, _ = json.Marshal(counter)
+		compact, _ = json.Marshal(Compact[PNCounter[string, int]]{Value: counter, Binary: true})
+		if len(compact) >= len(plain) {
+			t.Errorf("PNCounter of %d: compact %d bytes, plain JSON %d bytes", size, len(compact), len(plain))
+		}
+	}
+}
+
+func benchGSet(size int) GSet[int] {
+	set := GSet[int]{}
+	for i := range size {
+		set.Add(i * 3)
+	}
+	return set
+}
+
+func benchPNCounter(size int) PNCounter[string, int] {
+	c := NewPNCounter[string, int]()
+	for i := range size {
+		c.Increment(fmt.Sprintf("n%d", i), i*100)
+		c.Increment(fmt.Sprintf("n%d", i), -i)
+	}
+	return c
+}
+
+func benchmarkEncoding[T encoding.BinaryMarshaler](b *testing.B, name string, v T) {
+	b.Run(name+"/json", func(b *testing.B) {
+		var size int
+		for b.Loop() {
+			data, err := json.Marshal(v)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := json.Unmarshal(data, new(T)); err != nil {
+				b.Fatal(err)
+			}
+			size = len(data)
+		}
+		b.ReportMetric(float64(size), "bytes/msg")
+	})
+	b.Run(name+"/compact", func(b *testing.B) {
+		var size int
+		for b.Loop() {
+			data, err := json.Marshal(Compact[T]{Value: v, Binary: true})
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := json.Unmarshal(data, new(Compact[T])); err != nil {
+				b.Fatal(err)
+			}
+			size = len(data)
+		}
+		b.ReportMetric(float64(size), "bytes/msg")
+	})
+}
+
+// Round trip of a gossip payload in the plain JSON and the compact encoding
+func BenchmarkEncoding(b *testing.B) {
+	for _, size := range []int{10, 1000} {
+		benchmarkEncoding(b, fmt.Sprintf("GSet-%d", size), benchGSet(size))
+		benchmarkEncoding(b, fmt.Sprintf("PNCounter-%d", size), benchPNCounter(size))
+	}
+}
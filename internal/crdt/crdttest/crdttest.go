@@ -0,0 +1,204 @@
+// Package crdttest checks the join-semilattice laws every state-based CRDT
+// must obey, on states produced by random histories of operations and merges.
+package crdttest
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type Mergeable[T any] interface {
+	Merge(other T)
+	Copy() T
+}
+
+type Config[T Mergeable[T]] struct {
+	// New returns an empty replica.
+	New func() T
+	// Op applies a random operation issued by node to state.
+	Op func(r *rand.Rand, node string, state T)
+	// Equal compares two states, reflect.DeepEqual when nil.
+	Equal func(a, b T) bool
+
+	// Replicas is the number of nodes in each history, 3 when zero.
+	Replicas int
+	// Steps is the number of operations and merges per history, 30 when zero.
+	Steps int
+	// Histories is the number of random histories checked, 100 when zero.
+	Histories int
+	// Seed makes the run reproducible, a random seed is used when zero.
+	Seed uint64
+}
+
+func (c Config[T]) withDefaults() Config[T] {
+	if c.Equal == nil {
+		c.Equal = func(a, b T) bool { return reflect.DeepEqual(a, b) }
+	}
+	if c.Replicas == 0 {
+		c.Replicas = 3
+	}
+	if c.Steps == 0 {
+		c.Steps = 30
+	}
+	if c.Histories == 0 {
+		c.Histories = 100
+	}
+	if c.Seed == 0 {
+		c.Seed = rand.Uint64()
+	}
+	return c
+}
+
+// history is the outcome of replicas applying random operations and merging
+// each other's states: every intermediate state and the final replicas.
+type history[T any] struct {
+	states     []T
+	replicas   []T
+	inflations [][2]T
+}
+
+func newHistory[T Mergeable[T]](r *rand.Rand, cfg Config[T]) history[T] {
+	h := history[T]{states: []T{cfg.New()}}
+	for range cfg.Replicas {
+		h.replicas = append(h.replicas, cfg.New())
+	}
+	for range cfg.Steps {
+		i := r.IntN(cfg.Replicas)
+		before := h.replicas[i].Copy()
+		if j := r.IntN(cfg.Replicas); j != i && r.IntN(3) == 0 {
+			h.replicas[i].Merge(h.replicas[j].Copy())
+		} else {
+			cfg.Op(r, "n"+strconv.Itoa(i), h.replicas[i])
+		}
+		after := h.replicas[i].Copy()
+		h.states = append(h.states, after)
+		h.inflations = append(h.inflations, [2]T{before, after})
+	}
+	return h
+}
+
+func join[T Mergeable[T]](a, b T) T {
+	out := a.Copy()
+	out.Merge(b.Copy())
+	return out
+}
+
+func pick[T any](r *rand.Rand, states []T) T {
+	return states[r.IntN(len(states))]
+}
+
+type law[T Mergeable[T]] struct {
+	name  string
+	check func(r *rand.Rand, cfg Config[T], h history[T]) error
+}
+
+func laws[T Mergeable[T]]() []law[T] {
+	return []law[T]{
+		{"commutativity", checkCommutativity[T]},
+		{"associativity", checkAssociativity[T]},
+		{"idempotence", checkIdempotence[T]},
+		{"inflation", checkInflation[T]},
+		{"convergence", checkConvergence[T]},
+	}
+}
+
+// merge(a,b) == merge(b,a)
+func checkCommutativity[T Mergeable[T]](r *rand.Rand, cfg Config[T], h history[T]) error {
+	a, b := pick(r, h.states), pick(r, h.states)
+	if ab, ba := join(a, b), join(b, a); !cfg.Equal(ab, ba) {
+		return fmt.Errorf("a=%v b=%v: merge(a,b)=%v, merge(b,a)=%v", a, b, ab, ba)
+	}
+	return nil
+}
+
+// merge(merge(a,b),c) == merge(a,merge(b,c))
+func checkAssociativity[T Mergeable[T]](r *rand.Rand, cfg Config[T], h history[T]) error {
+	a, b, c := pick(r, h.states), pick(r, h.states), pick(r, h.states)
+	if left, right := join(join(a, b), c), join(a, join(b, c)); !cfg.Equal(left, right) {
+		return fmt.Errorf("a=%v b=%v c=%v: merge(merge(a,b),c)=%v, merge(a,merge(b,c))=%v", a, b, c, left, right)
+	}
+	return nil
+}
+
+// merge(a,a) == a
+func checkIdempotence[T Mergeable[T]](r *rand.Rand, cfg Config[T], h history[T]) error {
+	a := pick(r, h.states)
+	if aa := join(a, a); !cfg.Equal(aa, a) {
+		return fmt.Errorf("a=%v: merge(a,a)=%v", a, aa)
+	}
+	return nil
+}
+
+// Every operation and merge only moves a replica up the lattice: the state
+// before it is subsumed by the state after it, merge(before,after) == after.
+func checkInflation[T Mergeable[T]](_ *rand.Rand, cfg Config[T], h history[T]) error {
+	for _, step := range h.inflations {
+		before, after := step[0], step[1]
+		if joined := join(before, after); !cfg.Equal(joined, after) {
+			return fmt.Errorf("before=%v after=%v: merge(before,after)=%v", before, after, joined)
+		}
+	}
+	return nil
+}
+
+// Replicas that receive every state of the history, in any order, with
+// duplicates and stale states, end up equal.
+func checkConvergence[T Mergeable[T]](r *rand.Rand, cfg Config[T], h history[T]) error {
+	var first T
+	for i := range h.replicas {
+		deliveries := append(append([]T{}, h.states...), h.replicas...)
+		r.Shuffle(len(deliveries), func(a, b int) { deliveries[a], deliveries[b] = deliveries[b], deliveries[a] })
+		deliveries = append(deliveries, deliveries[:r.IntN(len(deliveries))]...)
+
+		replica := h.replicas[i].Copy()
+		for _, state := range deliveries {
+			replica.Merge(state.Copy())
+		}
+		if i == 0 {
+			first = replica
+			continue
+		}
+		if !cfg.Equal(first, replica) {
+			return fmt.Errorf("replica n0 converged to %v, replica n%d to %v", first, i, replica)
+		}
+	}
+	return nil
+}
+
+// Check verifies every law and returns the first violation found.
+func Check[T Mergeable[T]](cfg Config[T]) error {
+	cfg = cfg.withDefaults()
+	for _, l := range laws[T]() {
+		if err := checkLaw(cfg, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkLaw[T Mergeable[T]](cfg Config[T], l law[T]) error {
+	r := rand.New(rand.NewPCG(cfg.Seed, 0))
+	for range cfg.Histories {
+		h := newHistory(r, cfg)
+		if err := l.check(r, cfg, h); err != nil {
+			return fmt.Errorf("%s violated (seed %d): %w", l.name, cfg.Seed, err)
+		}
+	}
+	return nil
+}
+
+// Run verifies every law in its own subtest.
+func Run[T Mergeable[T]](t *testing.T, cfg Config[T]) {
+	t.Helper()
+	cfg = cfg.withDefaults()
+	for _, l := range laws[T]() {
+		t.Run(l.name, func(t *testing.T) {
+			if err := checkLaw(cfg, l); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package crdttest
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// register is a tiny lattice over ints whose merge is pluggable, so the
+// checker can be pointed at both lawful and broken merges.
+type register struct {
+	v     int
+	merge func(a, b int) int
+}
+
+func (r *register) Merge(other *register) { r.v = r.merge(r.v, other.v) }
+
+func (r *register) Copy() *register { return &register{v: r.v, merge: r.merge} }
+
+func registerConfig(merge func(a, b int) int) Config[*register] {
+	return Config[*register]{
+		New: func() *register { return &register{merge: merge} },
+		Op: func(r *rand.Rand, _ string, state *register) {
+			state.v = merge(state.v, state.v+r.IntN(10))
+		},
+		Equal: func(a, b *register) bool { return a.v == b.v },
+		Seed:  1,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		merge   func(a, b int) int
+		wantErr string
+	}{
+		{
+			name:  "max is a lattice",
+			merge: func(a, b int) int { return max(a, b) },
+		},
+		{
+			name:    "overwrite is not commutative",
+			merge:   func(_, b int) int { return b },
+			wantErr: "commutativity violated",
+		},
+		{
+			name:    "sum is not idempotent",
+			merge:   func(a, b int) int { return a + b },
+			wantErr: "idempotence violated",
+		},
+		{
+			name:    "average is not associative",
+			merge:   func(a, b int) int { return (a + b) / 2 },
+			wantErr: "associativity violated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(registerConfig(tt.merge))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Check() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck_Inflation(t *testing.T) {
+	cfg := registerConfig(func(a, b int) int { return max(a, b) })
+	cfg.Op = func(_ *rand.Rand, _ string, state *register) { state.v-- }
+
+	if err := Check(cfg); err == nil || !strings.Contains(err.Error(), "inflation violated") {
+		t.Errorf("Check() error = %v, want inflation violation", err)
+	}
+}
+
+func TestCheck_ReportsSeed(t *testing.T) {
+	cfg := registerConfig(func(_, b int) int { return b })
+	cfg.Seed = 42
+
+	if err := Check(cfg); err == nil || !strings.Contains(err.Error(), "seed 42") {
+		t.Errorf("Check() error = %v, want seed in message", err)
+	}
+}
@@ -0,0 +1,135 @@
+package crdt
+
+// maxBufferedDeltas caps the delta log; peers that fall further behind than
+// this are caught up with the full state instead.
+const maxBufferedDeltas = 1024
+
+// Delta is a state-based CRDT whose mutators can return delta-states. A delta
+// is just a (small) state of the same type, so deltas are applied with Merge.
+type Delta[T any] interface {
+	Merge(other T)
+	Copy() T
+}
+
+type deltaEntry[T any] struct {
+	seq   int
+	delta T
+	// from is the peer the delta was received from, if any
+	from string
+}
+
+// DeltaBuffer keeps the deltas produced since the oldest acknowledgement of any
+// peer, so every peer can be sent only what it has not seen yet.
+type DeltaBuffer[T Delta[T]] struct {
+	empty  func() T
+	seq    int
+	base   int
+	deltas []deltaEntry[T]
+	acked  map[string]int
+}
+
+func NewDeltaBuffer[T Delta[T]](peers []string, empty func() T) *DeltaBuffer[T] {
+	acked := make(map[string]int, len(peers))
+	for _, peer := range peers {
+		acked[peer] = 0
+	}
+	return &DeltaBuffer[T]{
+		empty: empty,
+		acked: acked,
+	}
+}
+
+// AddPeer starts tracking peer. It is sent every delta still buffered, or the
+// full state if some it needs were dropped already. Known peers keep their
+// acknowledgements.
+func (b *DeltaBuffer[T]) AddPeer(peer string) {
+	if _, ok := b.acked[peer]; !ok {
+		b.acked[peer] = 0
+	}
+}
+
+// Add records a delta produced by a local mutation.
+func (b *DeltaBuffer[T]) Add(delta T) {
+	b.AddFrom(delta, "")
+}
+
+// AddFrom records a delta received from peer from, which is not sent back to
+// it.
+func (b *DeltaBuffer[T]) AddFrom(delta T, from string) {
+	b.seq++
+	b.deltas = append(b.deltas, deltaEntry[T]{seq: b.seq, delta: delta.Copy(), from: from})
+	if len(b.deltas) > maxBufferedDeltas {
+		b.base = b.deltas[0].seq
+		b.deltas = b.deltas[1:]
+	}
+	if _, ok := b.acked[from]; ok {
+		b.skipOwn(from)
+	}
+}
+
+// Pending reports whether peer has not acknowledged every buffered delta.
+func (b *DeltaBuffer[T]) Pending(peer string) bool {
+	return b.acked[peer] < b.seq
+}
+
+// Since returns the join of all deltas peer has not acknowledged and the
+// sequence number to acknowledge once it is delivered. When the deltas peer
+// needs were already dropped, full is true and the caller must send its whole
+// state instead of the returned group.
+func (b *DeltaBuffer[T]) Since(peer string) (group T, seq int, full bool) {
+	acked := b.acked[peer]
+	group = b.empty()
+	if acked < b.base {
+		return group, b.seq, true
+	}
+	for _, entry := range b.deltas {
+		if entry.seq > acked && entry.from != peer {
+			group.Merge(entry.delta)
+		}
+	}
+	return group, b.seq, false
+}
+
+// Ack marks every delta up to seq as delivered to peer and drops the deltas
+// that all peers have acknowledged.
+func (b *DeltaBuffer[T]) Ack(peer string, seq int) {
+	b.acked[peer] = max(b.acked[peer], seq)
+	b.skipOwn(peer)
+
+	lowest := b.seq
+	for _, acked := range b.acked {
+		lowest = min(lowest, acked)
+	}
+	drop := 0
+	for drop < len(b.deltas) && b.deltas[drop].seq <= lowest {
+		drop++
+	}
+	if drop > 0 {
+		b.base = b.deltas[drop-1].seq
+		b.deltas = b.deltas[drop:]
+	}
+}
+
+// skipOwn acknowledges for peer the deltas that directly follow its
+// acknowledgement and came from peer itself, so that Pending does not report
+// deltas it would not be sent.
+func (b *DeltaBuffer[T]) skipOwn(peer string) {
+	if b.acked[peer] < b.base {
+		return
+	}
+	for _, entry := range b.deltas {
+		if entry.seq <= b.acked[peer] {
+			continue
+		}
+		if entry.from != peer {
+			return
+		}
+		b.acked[peer] = entry.seq
+	}
+}
+
+// Reset forgets everything peer acknowledged, e.g. after it restarted with an
+// empty state. Its next Since falls back to the full state if needed.
+func (b *DeltaBuffer[T]) Reset(peer string) {
+	b.acked[peer] = 0
+}
@@ -0,0 +1,253 @@
+package crdt
+
+import (
+	"slices"
+	"testing"
+)
+
+func newTestGSet() GSet[int] { return GSet[int]{} }
+
+func sortedSet(c GSet[int]) []int {
+	got := c.Elements()
+	slices.Sort(got)
+	return got
+}
+
+func TestDeltaBuffer_Since(t *testing.T) {
+	tests := []struct {
+		name     string
+		adds     []int
+		acked    int
+		want     []int
+		wantFull bool
+	}{
+		{
+			name: "nothing buffered",
+			want: []int{},
+		},
+		{
+			name: "nothing acknowledged",
+			adds: []int{1, 2, 3},
+			want: []int{1, 2, 3},
+		},
+		{
+			name:  "partially acknowledged",
+			adds:  []int{1, 2, 3},
+			acked: 2,
+			want:  []int{3},
+		},
+		{
+			name:  "fully acknowledged",
+			adds:  []int{1, 2, 3},
+			acked: 3,
+			want:  []int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewDeltaBuffer([]string{"n1", "n2"}, newTestGSet)
+			for _, element := range tt.adds {
+				b.Add(GSet[int]{element: {}})
+			}
+			b.Ack("n1", tt.acked)
+
+			group, seq, full := b.Since("n1")
+			if full != tt.wantFull {
+				t.Errorf("Since() full = %v, want %v", full, tt.wantFull)
+			}
+			if seq != len(tt.adds) {
+				t.Errorf("Since() seq = %d, want %d", seq, len(tt.adds))
+			}
+			if got := sortedSet(group); !slices.Equal(got, tt.want) {
+				t.Errorf("Since() group = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeltaBuffer_Pending(t *testing.T) {
+	b := NewDeltaBuffer([]string{"n1"}, newTestGSet)
+	if b.Pending("n1") {
+		t.Errorf("Pending() = true on empty buffer")
+	}
+	b.Add(GSet[int]{1: {}})
+	if !b.Pending("n1") {
+		t.Errorf("Pending() = false with unacknowledged delta")
+	}
+	_, seq, _ := b.Since("n1")
+	b.Ack("n1", seq)
+	if b.Pending("n1") {
+		t.Errorf("Pending() = true after Ack")
+	}
+}
+
+// Deltas added while a group is in flight are not covered by its ack
+func TestDeltaBuffer_AckInFlight(t *testing.T) {
+	b := NewDeltaBuffer([]string{"n1"}, newTestGSet)
+	b.Add(GSet[int]{1: {}})
+	_, seq, _ := b.Since("n1")
+	b.Add(GSet[int]{2: {}})
+	b.Ack("n1", seq)
+
+	group, _, full := b.Since("n1")
+	if full {
+		t.Fatalf("Since() full = true, want false")
+	}
+	if got := sortedSet(group); !slices.Equal(got, []int{2}) {
+		t.Errorf("Since() group = %v, want [2]", got)
+	}
+}
+
+// Deltas acknowledged by every peer are dropped, the slowest peer still gets its own
+func TestDeltaBuffer_Ack_DropsDelivered(t *testing.T) {
+	b := NewDeltaBuffer([]string{"n1", "n2"}, newTestGSet)
+	b.Add(GSet[int]{1: {}})
+	b.Add(GSet[int]{2: {}})
+	b.Ack("n1", 2)
+	b.Ack("n2", 1)
+
+	if got := len(b.deltas); got != 1 {
+		t.Errorf("len(deltas) = %d, want 1", got)
+	}
+	group, _, full := b.Since("n2")
+	if full {
+		t.Fatalf("Since() full = true, want false")
+	}
+	if got := sortedSet(group); !slices.Equal(got, []int{2}) {
+		t.Errorf("Since() group = %v, want [2]", got)
+	}
+}
+
+func TestDeltaBuffer_AddFrom(t *testing.T) {
+	tests := []struct {
+		name        string
+		ackedFirst  bool
+		wantPending bool
+		want        []int
+	}{
+		{name: "up-to-date sender is not sent its own delta", ackedFirst: true},
+		{name: "lagging sender gets only the others", wantPending: true, want: []int{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewDeltaBuffer([]string{"n1", "n2"}, newTestGSet)
+			b.Add(GSet[int]{1: {}})
+			if tt.ackedFirst {
+				b.Ack("n1", 1)
+			}
+			b.AddFrom(GSet[int]{2: {}}, "n1")
+			if !tt.ackedFirst {
+				b.Add(GSet[int]{3: {}})
+			}
+
+			if got := b.Pending("n1"); got != tt.wantPending {
+				t.Errorf("Pending(n1) = %v, want %v", got, tt.wantPending)
+			}
+			if tt.wantPending {
+				group, _, _ := b.Since("n1")
+				if got := sortedSet(group); !slices.Equal(got, tt.want) {
+					t.Errorf("Since(n1) group = %v, want %v", got, tt.want)
+				}
+			}
+			group, _, _ := b.Since("n2")
+			if got := sortedSet(group); !slices.Contains(got, 2) {
+				t.Errorf("Since(n2) group = %v, want it to contain 2", got)
+			}
+		})
+	}
+}
+
+// The sender's own delta is acknowledged along with the one it was waiting for
+func TestDeltaBuffer_AddFrom_InFlight(t *testing.T) {
+	b := NewDeltaBuffer([]string{"n1"}, newTestGSet)
+	b.Add(GSet[int]{1: {}})
+	_, seq, _ := b.Since("n1")
+	b.AddFrom(GSet[int]{2: {}}, "n1")
+	b.Ack("n1", seq)
+
+	if b.Pending("n1") {
+		t.Errorf("Pending() = true, want its own delta skipped")
+	}
+	if got := len(b.deltas); got != 0 {
+		t.Errorf("len(deltas) = %d, want 0", got)
+	}
+}
+
+func TestDeltaBuffer_Reset(t *testing.T) {
+	b := NewDeltaBuffer([]string{"n1"}, newTestGSet)
+	b.Add(GSet[int]{1: {}})
+	b.Ack("n1", 1)
+	b.Add(GSet[int]{2: {}})
+
+	b.Reset("n1")
+	if _, _, full := b.Since("n1"); !full {
+		t.Errorf("Since() after Reset full = false, want true")
+	}
+
+	_, seq, _ := b.Since("n1")
+	b.Ack("n1", seq)
+	if _, _, full := b.Since("n1"); full {
+		t.Errorf("Since() after full sync full = true, want false")
+	}
+}
+
+func TestDeltaBuffer_AddPeer(t *testing.T) {
+	b := NewDeltaBuffer(nil, newTestGSet)
+	b.Add(GSet[int]{1: {}})
+	b.AddPeer("n1")
+	group, seq, full := b.Since("n1")
+	if full {
+		t.Fatalf("Since() full = true, want false")
+	}
+	if got := sortedSet(group); !slices.Equal(got, []int{1}) {
+		t.Errorf("Since() group = %v, want [1]", got)
+	}
+	b.Ack("n1", seq)
+
+	// Deltas acknowledged by every known peer are gone, so a late peer needs
+	// the full state
+	b.AddPeer("n2")
+	if _, _, full := b.Since("n2"); !full {
+		t.Errorf("Since() for late peer full = false, want true")
+	}
+	b.AddPeer("n1")
+	if b.Pending("n1") {
+		t.Errorf("Pending() = true after adding known peer again")
+	}
+}
+
+func TestDeltaBuffer_Overflow(t *testing.T) {
+	b := NewDeltaBuffer([]string{"n1", "n2"}, newTestGSet)
+	b.Add(GSet[int]{0: {}})
+	b.Ack("n2", 1)
+	for i := 1; i <= maxBufferedDeltas; i++ {
+		b.Add(GSet[int]{i: {}})
+	}
+
+	if _, _, full := b.Since("n1"); !full {
+		t.Errorf("Since() for lagging peer full = false, want true")
+	}
+	if _, _, full := b.Since("n2"); full {
+		t.Errorf("Since() for up-to-date peer full = true, want false")
+	}
+}
+
+// Convergence: shipping only delta groups yields the same state as shipping full state
+func TestDeltaBuffer_Converges(t *testing.T) {
+	local := NewPNCounter[string, int]()
+	remote := NewPNCounter[string, int]()
+	b := NewDeltaBuffer([]string{"n1"}, NewPNCounter[string, int])
+
+	for i, delta := range []int{5, -2, 7, -1} {
+		b.Add(local.Increment("n0", delta))
+		if i%2 == 1 {
+			group, seq, _ := b.Since("n1")
+			remote.Merge(group)
+			b.Ack("n1", seq)
+		}
+	}
+
+	if got, want := remote.Value(), local.Value(); got != want {
+		t.Errorf("remote Value() = %d, want %d", got, want)
+	}
+}
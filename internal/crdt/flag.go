@@ -0,0 +1,118 @@
+package crdt
+
+import "encoding/json"
+
+// Flag tokens: Enable and Disable each tag the flag with their token and drop
+// every tag of the opposite token they have observed. A concurrent Enable and
+// Disable leave both tokens live, which the flag types resolve differently.
+const (
+	enableToken  = "enable"
+	disableToken = "disable"
+)
+
+func setFlag(tokens ORSet[string], node, token, opposite string) {
+	tokens.Remove(node, opposite)
+	tokens.Add(node, token)
+}
+
+// EWFlag is an enable-wins flag: it is enabled when an Enable has not been
+// followed by an observed Disable, so an Enable concurrent with a Disable wins.
+// A new flag is disabled.
+type EWFlag struct {
+	Tokens ORSet[string] `json:"tokens"`
+}
+
+func NewEWFlag() EWFlag {
+	return EWFlag{Tokens: NewORSet[string]()}
+}
+
+func (f EWFlag) Enable(node string) {
+	setFlag(f.Tokens, node, enableToken, disableToken)
+}
+
+func (f EWFlag) Disable(node string) {
+	setFlag(f.Tokens, node, disableToken, enableToken)
+}
+
+func (f EWFlag) Value() bool {
+	return f.Tokens.Contains(enableToken)
+}
+
+func (f EWFlag) Merge(other EWFlag) {
+	f.Tokens.Merge(other.Tokens)
+}
+
+func (f EWFlag) Copy() EWFlag {
+	return EWFlag{Tokens: f.Tokens.Copy()}
+}
+
+func (f EWFlag) Equal(other EWFlag) bool {
+	return f.Tokens.Equal(other.Tokens)
+}
+
+func (f EWFlag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ewFlagJSON(f))
+}
+
+func (f *EWFlag) UnmarshalJSON(data []byte) error {
+	fields := ewFlagJSON(NewEWFlag())
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*f = EWFlag(fields)
+	return nil
+}
+
+// ewFlagJSON has the fields of EWFlag but none of its methods.
+type ewFlagJSON EWFlag
+
+// DWFlag is a disable-wins flag: it is enabled when an Enable has not been
+// followed by an observed Disable and no Disable is concurrent with it, so a
+// Disable concurrent with an Enable wins. A new flag is disabled.
+type DWFlag struct {
+	Tokens ORSet[string] `json:"tokens"`
+}
+
+func NewDWFlag() DWFlag {
+	return DWFlag{Tokens: NewORSet[string]()}
+}
+
+func (f DWFlag) Enable(node string) {
+	setFlag(f.Tokens, node, enableToken, disableToken)
+}
+
+func (f DWFlag) Disable(node string) {
+	setFlag(f.Tokens, node, disableToken, enableToken)
+}
+
+func (f DWFlag) Value() bool {
+	return f.Tokens.Contains(enableToken) && !f.Tokens.Contains(disableToken)
+}
+
+func (f DWFlag) Merge(other DWFlag) {
+	f.Tokens.Merge(other.Tokens)
+}
+
+func (f DWFlag) Copy() DWFlag {
+	return DWFlag{Tokens: f.Tokens.Copy()}
+}
+
+func (f DWFlag) Equal(other DWFlag) bool {
+	return f.Tokens.Equal(other.Tokens)
+}
+
+func (f DWFlag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dwFlagJSON(f))
+}
+
+func (f *DWFlag) UnmarshalJSON(data []byte) error {
+	fields := dwFlagJSON(NewDWFlag())
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*f = DWFlag(fields)
+	return nil
+}
+
+// dwFlagJSON has the fields of DWFlag but none of its methods.
+type dwFlagJSON DWFlag
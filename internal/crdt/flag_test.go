@@ -0,0 +1,250 @@
+package crdt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type flagOp struct {
+	node   string
+	enable bool
+}
+
+// flag is the behaviour EWFlag and DWFlag share, so both run the same tables.
+type flag interface {
+	Enable(node string)
+	Disable(node string)
+	Value() bool
+}
+
+func applyFlagOps(f flag, ops []flagOp) {
+	for _, op := range ops {
+		if op.enable {
+			f.Enable(op.node)
+		} else {
+			f.Disable(op.node)
+		}
+	}
+}
+
+func TestFlag_EnableDisable(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []flagOp
+		want bool
+	}{
+		{
+			name: "new flag is disabled",
+			want: false,
+		},
+		{
+			name: "enable",
+			ops:  []flagOp{{"n0", true}},
+			want: true,
+		},
+		{
+			name: "disable new flag",
+			ops:  []flagOp{{"n0", false}},
+			want: false,
+		},
+		{
+			name: "enable then disable",
+			ops:  []flagOp{{"n0", true}, {"n0", false}},
+			want: false,
+		},
+		{
+			name: "disable then enable",
+			ops:  []flagOp{{"n0", false}, {"n0", true}},
+			want: true,
+		},
+		{
+			name: "enable twice",
+			ops:  []flagOp{{"n0", true}, {"n1", true}},
+			want: true,
+		},
+		{
+			name: "disable after enables of several nodes",
+			ops:  []flagOp{{"n0", true}, {"n1", true}, {"n2", false}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, f := range map[string]flag{"EWFlag": NewEWFlag(), "DWFlag": NewDWFlag()} {
+				applyFlagOps(f, tt.ops)
+				if got := f.Value(); got != tt.want {
+					t.Errorf("%s Value() = %v, want %v", name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFlag_Merge(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   []flagOp
+		a      []flagOp
+		b      []flagOp
+		wantEW bool
+		wantDW bool
+	}{
+		{
+			name:   "merge with empty",
+			a:      []flagOp{{"n0", true}},
+			wantEW: true,
+			wantDW: true,
+		},
+		{
+			name:   "concurrent enables",
+			a:      []flagOp{{"n0", true}},
+			b:      []flagOp{{"n1", true}},
+			wantEW: true,
+			wantDW: true,
+		},
+		{
+			name:   "concurrent disables",
+			base:   []flagOp{{"n0", true}},
+			a:      []flagOp{{"n0", false}},
+			b:      []flagOp{{"n1", false}},
+			wantEW: false,
+			wantDW: false,
+		},
+		{
+			name:   "enable concurrent with disable",
+			base:   []flagOp{{"n0", true}},
+			a:      []flagOp{{"n0", false}},
+			b:      []flagOp{{"n1", true}},
+			wantEW: true,
+			wantDW: false,
+		},
+		{
+			name:   "observed disable",
+			base:   []flagOp{{"n0", true}},
+			a:      []flagOp{{"n1", false}},
+			wantEW: false,
+			wantDW: false,
+		},
+		{
+			name:   "enable concurrent with disable on a new flag",
+			a:      []flagOp{{"n0", true}},
+			b:      []flagOp{{"n1", false}},
+			wantEW: true,
+			wantDW: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ew := NewEWFlag()
+			applyFlagOps(ew, tt.base)
+			ewA, ewB := ew.Copy(), ew.Copy()
+			applyFlagOps(ewA, tt.a)
+			applyFlagOps(ewB, tt.b)
+			ewA.Merge(ewB)
+			if got := ewA.Value(); got != tt.wantEW {
+				t.Errorf("EWFlag Value() after Merge = %v, want %v", got, tt.wantEW)
+			}
+
+			dw := NewDWFlag()
+			applyFlagOps(dw, tt.base)
+			dwA, dwB := dw.Copy(), dw.Copy()
+			applyFlagOps(dwA, tt.a)
+			applyFlagOps(dwB, tt.b)
+			dwA.Merge(dwB)
+			if got := dwA.Value(); got != tt.wantDW {
+				t.Errorf("DWFlag Value() after Merge = %v, want %v", got, tt.wantDW)
+			}
+		})
+	}
+}
+
+// A later Enable that has observed the conflict settles a disable-wins flag
+func TestDWFlag_Merge_ResolvedConflict(t *testing.T) {
+	a, b := NewDWFlag(), NewDWFlag()
+	a.Enable("n0")
+	b.Disable("n1")
+	a.Merge(b)
+	if a.Value() {
+		t.Fatalf("Value() with concurrent Disable = true, want false")
+	}
+	a.Enable("n0")
+	if !a.Value() {
+		t.Errorf("Value() after observing Enable = false, want true")
+	}
+}
+
+func TestEWFlag_Merge_Commutative(t *testing.T) {
+	a, b := NewEWFlag(), NewEWFlag()
+	applyFlagOps(a, []flagOp{{"n0", true}, {"n0", false}})
+	applyFlagOps(b, []flagOp{{"n1", true}})
+
+	ab := a.Copy()
+	ab.Merge(b)
+	ba := b.Copy()
+	ba.Merge(a)
+	if !ab.Equal(ba) {
+		t.Errorf("commutativity violated: merge(a,b)=%v, merge(b,a)=%v", ab.Value(), ba.Value())
+	}
+}
+
+func TestDWFlag_Merge_Commutative(t *testing.T) {
+	a, b := NewDWFlag(), NewDWFlag()
+	applyFlagOps(a, []flagOp{{"n0", true}, {"n0", false}})
+	applyFlagOps(b, []flagOp{{"n1", true}})
+
+	ab := a.Copy()
+	ab.Merge(b)
+	ba := b.Copy()
+	ba.Merge(a)
+	if !ab.Equal(ba) {
+		t.Errorf("commutativity violated: merge(a,b)=%v, merge(b,a)=%v", ab.Value(), ba.Value())
+	}
+}
+
+func TestFlag_Copy(t *testing.T) {
+	ew := NewEWFlag()
+	ew.Enable("n0")
+	ewCopy := ew.Copy()
+	ew.Disable("n0")
+	if !ewCopy.Value() {
+		t.Errorf("EWFlag Copy() was affected by mutation of original")
+	}
+
+	dw := NewDWFlag()
+	dw.Enable("n0")
+	dwCopy := dw.Copy()
+	dw.Disable("n0")
+	if !dwCopy.Value() {
+		t.Errorf("DWFlag Copy() was affected by mutation of original")
+	}
+}
+
+func TestFlag_JSON(t *testing.T) {
+	ew := NewEWFlag()
+	applyFlagOps(ew, []flagOp{{"n0", true}, {"n1", false}})
+	data, err := json.Marshal(ew)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var gotEW EWFlag
+	if err := json.Unmarshal(data, &gotEW); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !gotEW.Equal(ew) {
+		t.Errorf("EWFlag round trip = %s, want equal state", data)
+	}
+
+	dw := NewDWFlag()
+	applyFlagOps(dw, []flagOp{{"n0", true}})
+	data, err = json.Marshal(dw)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var gotDW DWFlag
+	if err := json.Unmarshal(data, &gotDW); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !gotDW.Equal(dw) || !gotDW.Value() {
+		t.Errorf("DWFlag round trip = %s, want equal state", data)
+	}
+}
@@ -1,21 +1,72 @@
 package crdt
 
-type GCounter map[string]int
+import (
+	"encoding/json"
+	"maps"
+)
 
-func (c GCounter) Increment(key string, delta int) {
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+type GCounter[K comparable, V Integer] map[K]V
+
+// Increment bumps the entry for key and returns the delta-state holding just
+// that entry, ready to be merged into any replica.
+func (c GCounter[K, V]) Increment(key K, delta V) GCounter[K, V] {
 	c[key] += delta
+	return GCounter[K, V]{key: c[key]}
 }
 
-func (c GCounter) Value() int {
-	sum := 0
+func (c GCounter[K, V]) Value() V {
+	var sum V
 	for _, val := range c {
 		sum += val
 	}
 	return sum
 }
 
-func (c GCounter) Merge(other GCounter) {
+func (c GCounter[K, V]) Merge(other GCounter[K, V]) {
 	for key, val := range other {
 		c[key] = max(c[key], val)
 	}
 }
+
+// Compact drops zero entries, which Equal and Merge treat like missing ones.
+// Entries of departed nodes stay: they are part of the value, not metadata.
+func (c GCounter[K, V]) Compact() {
+	maps.DeleteFunc(c, func(_ K, val V) bool { return val == 0 })
+}
+
+func (c GCounter[K, V]) Copy() GCounter[K, V] {
+	return maps.Clone(c)
+}
+
+// Equal reports whether both counters hold the same entries, treating a
+// missing entry as zero.
+func (c GCounter[K, V]) Equal(other GCounter[K, V]) bool {
+	for key, val := range c {
+		if other[key] != val {
+			return false
+		}
+	}
+	for key, val := range other {
+		if c[key] != val {
+			return false
+		}
+	}
+	return true
+}
+
+func (c GCounter[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[K]V(c))
+}
+
+func (c *GCounter[K, V]) UnmarshalJSON(data []byte) error {
+	var m map[K]V
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*c = m
+	return nil
+}
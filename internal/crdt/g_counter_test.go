@@ -1,6 +1,10 @@
 package crdt
 
-import "testing"
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+)
 
 func TestGCounter_Increment(t *testing.T) {
 	type args struct {
@@ -9,35 +13,38 @@ func TestGCounter_Increment(t *testing.T) {
 	}
 	tests := []struct {
 		name      string
-		c         GCounter
+		c         GCounter[string, int]
 		args      args
 		wantValue int
 	}{
 		{
 			name:      "increment new key",
-			c:         GCounter{},
+			c:         GCounter[string, int]{},
 			args:      args{"n0", 5},
 			wantValue: 5,
 		},
 		{
 			name:      "increment existing key",
-			c:         GCounter{"n0": 5},
+			c:         GCounter[string, int]{"n0": 5},
 			args:      args{"n0", 3},
 			wantValue: 8,
 		},
 		{
 			name:      "increment zero",
-			c:         GCounter{"n0": 5},
+			c:         GCounter[string, int]{"n0": 5},
 			args:      args{"n0", 0},
 			wantValue: 5,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.c.Increment(tt.args.key, tt.args.delta)
+			delta := tt.c.Increment(tt.args.key, tt.args.delta)
 			if got := tt.c[tt.args.key]; got != tt.wantValue {
 				t.Errorf("got %d, want %d", got, tt.wantValue)
 			}
+			if want := (GCounter[string, int]{tt.args.key: tt.wantValue}); !maps.Equal(delta, want) {
+				t.Errorf("delta = %v, want %v", delta, want)
+			}
 		})
 	}
 }
@@ -45,22 +52,22 @@ func TestGCounter_Increment(t *testing.T) {
 func TestGCounter_Value(t *testing.T) {
 	tests := []struct {
 		name string
-		c    GCounter
+		c    GCounter[string, int]
 		want int
 	}{
 		{
 			name: "empty counter",
-			c:    GCounter{},
+			c:    GCounter[string, int]{},
 			want: 0,
 		},
 		{
 			name: "single node",
-			c:    GCounter{"n0": 7},
+			c:    GCounter[string, int]{"n0": 7},
 			want: 7,
 		},
 		{
 			name: "multiple nodes",
-			c:    GCounter{"n0": 5, "n1": 3, "n2": 2},
+			c:    GCounter[string, int]{"n0": 5, "n1": 3, "n2": 2},
 			want: 10,
 		},
 	}
@@ -75,48 +82,48 @@ func TestGCounter_Value(t *testing.T) {
 
 func TestGCounter_Merge(t *testing.T) {
 	type args struct {
-		other GCounter
+		other GCounter[string, int]
 	}
 	tests := []struct {
 		name      string
-		c         GCounter
+		c         GCounter[string, int]
 		args      args
 		wantValue int
 	}{
 		{
 			name:      "merge disjoint keys",
-			c:         GCounter{"n0": 5},
-			args:      args{GCounter{"n1": 3}},
+			c:         GCounter[string, int]{"n0": 5},
+			args:      args{GCounter[string, int]{"n1": 3}},
 			wantValue: 8,
 		},
 		{
 			name:      "max wins — local higher",
-			c:         GCounter{"n0": 10},
-			args:      args{GCounter{"n0": 6}},
+			c:         GCounter[string, int]{"n0": 10},
+			args:      args{GCounter[string, int]{"n0": 6}},
 			wantValue: 10,
 		},
 		{
 			name:      "max wins — remote higher",
-			c:         GCounter{"n0": 6},
-			args:      args{GCounter{"n0": 10}},
+			c:         GCounter[string, int]{"n0": 6},
+			args:      args{GCounter[string, int]{"n0": 10}},
 			wantValue: 10,
 		},
 		{
 			name:      "idempotent — merge with equal state",
-			c:         GCounter{"n0": 5, "n1": 3},
-			args:      args{GCounter{"n0": 5, "n1": 3}},
+			c:         GCounter[string, int]{"n0": 5, "n1": 3},
+			args:      args{GCounter[string, int]{"n0": 5, "n1": 3}},
 			wantValue: 8,
 		},
 		{
 			name:      "merge with empty",
-			c:         GCounter{"n0": 5},
-			args:      args{GCounter{}},
+			c:         GCounter[string, int]{"n0": 5},
+			args:      args{GCounter[string, int]{}},
 			wantValue: 5,
 		},
 		{
 			name:      "monotone — value never decreases",
-			c:         GCounter{"n0": 5},
-			args:      args{GCounter{"n0": 3}},
+			c:         GCounter[string, int]{"n0": 5},
+			args:      args{GCounter[string, int]{"n0": 3}},
 			wantValue: 5,
 		},
 	}
@@ -132,16 +139,71 @@ func TestGCounter_Merge(t *testing.T) {
 
 // Commutativity: merge(a,b) and merge(b,a) produce the same value
 func TestGCounter_Merge_Commutative(t *testing.T) {
-	a := GCounter{"n0": 5, "n1": 2}
-	b := GCounter{"n0": 3, "n1": 7}
+	a := GCounter[string, int]{"n0": 5, "n1": 2}
+	b := GCounter[string, int]{"n0": 3, "n1": 7}
 
-	ab := GCounter{"n0": 5, "n1": 2}
+	ab := GCounter[string, int]{"n0": 5, "n1": 2}
 	ab.Merge(b)
 
-	ba := GCounter{"n0": 3, "n1": 7}
+	ba := GCounter[string, int]{"n0": 3, "n1": 7}
 	ba.Merge(a)
 
 	if ab.Value() != ba.Value() {
 		t.Errorf("commutativity violated: merge(a,b)=%d, merge(b,a)=%d", ab.Value(), ba.Value())
 	}
 }
+
+func TestGCounter_Copy(t *testing.T) {
+	c := GCounter[string, int]{"n0": 5}
+	cpy := c.Copy()
+	c.Increment("n0", 10)
+	cpy.Increment("n1", 1)
+
+	if got := cpy.Value(); got != 6 {
+		t.Errorf("Copy() was affected by mutation of original: got %d, want 6", got)
+	}
+	if got := c.Value(); got != 15 {
+		t.Errorf("Original was affected by mutation of copy: got %d, want 15", got)
+	}
+}
+
+func TestGCounter_OtherInstantiations(t *testing.T) {
+	type shard struct {
+		node string
+		id   int
+	}
+	c := GCounter[shard, uint64]{}
+	c.Increment(shard{"n0", 1}, 5)
+	c.Merge(GCounter[shard, uint64]{{"n0", 1}: 3, {"n1", 2}: 4})
+	if got := c.Value(); got != 9 {
+		t.Errorf("Value() = %d, want 9", got)
+	}
+
+	c64 := GCounter[string, int64]{"n0": 1 << 40}
+	c64.Increment("n1", 1<<40)
+	if got := c64.Value(); got != 1<<41 {
+		t.Errorf("Value() = %d, want %d", got, int64(1<<41))
+	}
+}
+
+// Wire compatibility: the generic counter encodes exactly like the former map[string]int
+func TestGCounter_JSON_WireCompatible(t *testing.T) {
+	legacy := map[string]int{"n0": 5, "n1": 3}
+
+	got, err := json.Marshal(GCounter[string, int]{"n0": 5, "n1": 3})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want, _ := json.Marshal(legacy)
+	if string(got) != string(want) {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+
+	var decoded GCounter[string, int]
+	if err := json.Unmarshal(want, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !maps.Equal(decoded, GCounter[string, int](legacy)) {
+		t.Errorf("Unmarshal() = %v, want %v", decoded, legacy)
+	}
+}
@@ -1,21 +1,49 @@
 package crdt
 
-type GSet map[int]struct{}
+import (
+	"encoding/json"
+	"maps"
+)
 
-func (c GSet) Add(element int) {
+type GSet[T comparable] map[T]struct{}
+
+// Add inserts element and returns the delta-state holding just that element.
+func (c GSet[T]) Add(element T) GSet[T] {
 	c[element] = struct{}{}
+	return GSet[T]{element: {}}
 }
 
-func (c GSet) Elements() []int {
-	elements := make([]int, 0, len(c))
+func (c GSet[T]) Elements() []T {
+	elements := make([]T, 0, len(c))
 	for k := range c {
 		elements = append(elements, k)
 	}
 	return elements
 }
 
-func (c GSet) Merge(other GSet) {
+func (c GSet[T]) Merge(other GSet[T]) {
 	for key, val := range other {
 		c[key] = val
 	}
 }
+
+func (c GSet[T]) Copy() GSet[T] {
+	return maps.Clone(c)
+}
+
+func (c GSet[T]) Equal(other GSet[T]) bool {
+	return maps.Equal(c, other)
+}
+
+func (c GSet[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[T]struct{}(c))
+}
+
+func (c *GSet[T]) UnmarshalJSON(data []byte) error {
+	var m map[T]struct{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*c = m
+	return nil
+}
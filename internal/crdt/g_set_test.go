@@ -1,6 +1,8 @@
 package crdt
 
 import (
+	"encoding/json"
+	"maps"
 	"slices"
 	"testing"
 )
@@ -8,32 +10,35 @@ import (
 func TestGSet_Add(t *testing.T) {
 	tests := []struct {
 		name    string
-		c       GSet
+		c       GSet[int]
 		element int
 		wantLen int
 	}{
 		{
 			name:    "add to empty set",
-			c:       GSet{},
+			c:       GSet[int]{},
 			element: 1,
 			wantLen: 1,
 		},
 		{
 			name:    "add duplicate — no growth",
-			c:       GSet{1: {}},
+			c:       GSet[int]{1: {}},
 			element: 1,
 			wantLen: 1,
 		},
 		{
 			name:    "add distinct element",
-			c:       GSet{1: {}},
+			c:       GSet[int]{1: {}},
 			element: 2,
 			wantLen: 2,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.c.Add(tt.element)
+			delta := tt.c.Add(tt.element)
+			if want := (GSet[int]{tt.element: {}}); !maps.Equal(delta, want) {
+				t.Errorf("delta = %v, want %v", delta, want)
+			}
 			if got := len(tt.c); got != tt.wantLen {
 				t.Errorf("len = %d, want %d", got, tt.wantLen)
 			}
@@ -47,22 +52,22 @@ func TestGSet_Add(t *testing.T) {
 func TestGSet_Values(t *testing.T) {
 	tests := []struct {
 		name string
-		c    GSet
+		c    GSet[int]
 		want []int
 	}{
 		{
 			name: "empty set",
-			c:    GSet{},
+			c:    GSet[int]{},
 			want: []int{},
 		},
 		{
 			name: "single element",
-			c:    GSet{42: {}},
+			c:    GSet[int]{42: {}},
 			want: []int{42},
 		},
 		{
 			name: "multiple elements",
-			c:    GSet{1: {}, 2: {}, 3: {}},
+			c:    GSet[int]{1: {}, 2: {}, 3: {}},
 			want: []int{1, 2, 3},
 		},
 	}
@@ -81,38 +86,38 @@ func TestGSet_Values(t *testing.T) {
 func TestGSet_Merge(t *testing.T) {
 	tests := []struct {
 		name     string
-		c        GSet
-		other    GSet
+		c        GSet[int]
+		other    GSet[int]
 		wantKeys []int
 	}{
 		{
 			name:     "merge disjoint sets",
-			c:        GSet{1: {}},
-			other:    GSet{2: {}},
+			c:        GSet[int]{1: {}},
+			other:    GSet[int]{2: {}},
 			wantKeys: []int{1, 2},
 		},
 		{
 			name:     "merge overlapping sets",
-			c:        GSet{1: {}, 2: {}},
-			other:    GSet{2: {}, 3: {}},
+			c:        GSet[int]{1: {}, 2: {}},
+			other:    GSet[int]{2: {}, 3: {}},
 			wantKeys: []int{1, 2, 3},
 		},
 		{
 			name:     "idempotent — merge with equal state",
-			c:        GSet{1: {}, 2: {}},
-			other:    GSet{1: {}, 2: {}},
+			c:        GSet[int]{1: {}, 2: {}},
+			other:    GSet[int]{1: {}, 2: {}},
 			wantKeys: []int{1, 2},
 		},
 		{
 			name:     "merge with empty",
-			c:        GSet{1: {}},
-			other:    GSet{},
+			c:        GSet[int]{1: {}},
+			other:    GSet[int]{},
 			wantKeys: []int{1},
 		},
 		{
 			name:     "merge into empty",
-			c:        GSet{},
-			other:    GSet{1: {}, 2: {}},
+			c:        GSet[int]{},
+			other:    GSet[int]{1: {}, 2: {}},
 			wantKeys: []int{1, 2},
 		},
 	}
@@ -131,13 +136,13 @@ func TestGSet_Merge(t *testing.T) {
 
 // Commutativity: merge(a,b) and merge(b,a) contain the same elements
 func TestGSet_Merge_Commutative(t *testing.T) {
-	a := GSet{1: {}, 2: {}}
-	b := GSet{2: {}, 3: {}}
+	a := GSet[int]{1: {}, 2: {}}
+	b := GSet[int]{2: {}, 3: {}}
 
-	ab := GSet{1: {}, 2: {}}
+	ab := GSet[int]{1: {}, 2: {}}
 	ab.Merge(b)
 
-	ba := GSet{2: {}, 3: {}}
+	ba := GSet[int]{2: {}, 3: {}}
 	ba.Merge(a)
 
 	gotAB := ab.Elements()
@@ -149,3 +154,53 @@ func TestGSet_Merge_Commutative(t *testing.T) {
 		t.Errorf("commutativity violated: merge(a,b)=%v, merge(b,a)=%v", gotAB, gotBA)
 	}
 }
+
+func TestGSet_Copy(t *testing.T) {
+	c := GSet[int]{1: {}}
+	cpy := c.Copy()
+	c.Add(2)
+	cpy.Add(3)
+
+	got := cpy.Elements()
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("Copy() was affected by mutation of original: got %v", got)
+	}
+	got = c.Elements()
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("Original was affected by mutation of copy: got %v", got)
+	}
+}
+
+func TestGSet_OtherInstantiations(t *testing.T) {
+	c := GSet[string]{"a": {}}
+	c.Merge(GSet[string]{"b": {}})
+	got := c.Elements()
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Elements() = %v, want [a b]", got)
+	}
+}
+
+// Wire compatibility: the generic set encodes exactly like the former map[int]struct{}
+func TestGSet_JSON_WireCompatible(t *testing.T) {
+	legacy := map[int]struct{}{1: {}, 22: {}}
+
+	got, err := json.Marshal(GSet[int]{1: {}, 22: {}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want, _ := json.Marshal(legacy)
+	if string(got) != string(want) {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+
+	var decoded GSet[int]
+	if err := json.Unmarshal(want, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !maps.Equal(decoded, GSet[int](legacy)) {
+		t.Errorf("Unmarshal() = %v, want %v", decoded, legacy)
+	}
+}
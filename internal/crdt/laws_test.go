@@ -0,0 +1,152 @@
+package crdt
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"gossip-glomers/internal/crdt/crdttest"
+	"gossip-glomers/internal/hlc"
+)
+
+func TestGCounter_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[GCounter[string, int]]{
+		New: func() GCounter[string, int] { return GCounter[string, int]{} },
+		Op: func(r *rand.Rand, node string, c GCounter[string, int]) {
+			c.Increment(node, r.IntN(10))
+		},
+	})
+}
+
+func TestPNCounter_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[PNCounter[string, int]]{
+		New: NewPNCounter[string, int],
+		Op: func(r *rand.Rand, node string, c PNCounter[string, int]) {
+			c.Increment(node, r.IntN(21)-10)
+		},
+	})
+}
+
+func TestGSet_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[GSet[int]]{
+		New: func() GSet[int] { return GSet[int]{} },
+		Op: func(r *rand.Rand, _ string, c GSet[int]) {
+			c.Add(r.IntN(20))
+		},
+	})
+}
+
+func TestORSet_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[ORSet[int]]{
+		New: NewORSet[int],
+		Op: func(r *rand.Rand, node string, c ORSet[int]) {
+			if r.IntN(3) == 0 {
+				c.Remove(node, r.IntN(5))
+			} else {
+				c.Add(node, r.IntN(5))
+			}
+		},
+	})
+}
+
+func TestRangeSet_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[*RangeSet[int]]{
+		New: func() *RangeSet[int] { return NewRangeSet[int]() },
+		Op: func(r *rand.Rand, _ string, c *RangeSet[int]) {
+			c.Add(r.IntN(30))
+		},
+		Equal: func(a, b *RangeSet[int]) bool {
+			return slices.Equal(a.Ranges(), b.Ranges())
+		},
+	})
+}
+
+func TestLWWMap_Laws(t *testing.T) {
+	clocks := map[string]*hlc.Clock{}
+	crdttest.Run(t, crdttest.Config[LWWMap[int, int]]{
+		New: func() LWWMap[int, int] { return LWWMap[int, int]{} },
+		Op: func(r *rand.Rand, node string, m LWWMap[int, int]) {
+			if clocks[node] == nil {
+				clocks[node] = hlc.NewClock(node, nil)
+			}
+			if r.IntN(4) == 0 {
+				m.Delete(r.IntN(5), clocks[node].Now())
+			} else {
+				m.Set(r.IntN(5), r.IntN(100), clocks[node].Now())
+			}
+		},
+	})
+}
+
+func TestRGA_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[*RGA[int]]{
+		New: NewRGA[int],
+		Op: func(r *rand.Rand, node string, s *RGA[int]) {
+			ids := s.IDs()
+			if len(ids) > 0 && r.IntN(3) == 0 {
+				s.Delete(ids[r.IntN(len(ids))])
+				return
+			}
+			after := Dot{}
+			if len(ids) > 0 && r.IntN(4) != 0 {
+				after = ids[r.IntN(len(ids))]
+			}
+			s.InsertAfter(node, after, r.IntN(100))
+		},
+	})
+}
+
+func TestEWFlag_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[EWFlag]{
+		New: NewEWFlag,
+		Op: func(r *rand.Rand, node string, f EWFlag) {
+			if r.IntN(2) == 0 {
+				f.Enable(node)
+			} else {
+				f.Disable(node)
+			}
+		},
+	})
+}
+
+func TestDWFlag_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[DWFlag]{
+		New: NewDWFlag,
+		Op: func(r *rand.Rand, node string, f DWFlag) {
+			if r.IntN(2) == 0 {
+				f.Enable(node)
+			} else {
+				f.Disable(node)
+			}
+		},
+	})
+}
+
+func TestORMap_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[*ORMap[int, PNCounter[string, int]]]{
+		New: func() *ORMap[int, PNCounter[string, int]] {
+			m := NewORMap[int](NewPNCounter[string, int])
+			return &m
+		},
+		Op: func(r *rand.Rand, node string, m *ORMap[int, PNCounter[string, int]]) {
+			key := r.IntN(4)
+			if r.IntN(4) == 0 {
+				m.Remove(node, key)
+				return
+			}
+			delta := r.IntN(21) - 10
+			m.Update(node, key, func(c PNCounter[string, int]) { c.Increment(node, delta) })
+		},
+		// DeepEqual cannot compare the empty value constructor
+		Equal: (*ORMap[int, PNCounter[string, int]]).Equal,
+	})
+}
+
+func TestVersionVector_Laws(t *testing.T) {
+	crdttest.Run(t, crdttest.Config[VersionVector]{
+		New: func() VersionVector { return VersionVector{} },
+		Op: func(_ *rand.Rand, node string, v VersionVector) {
+			v.Increment(node)
+		},
+	})
+}
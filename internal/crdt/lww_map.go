@@ -0,0 +1,68 @@
+package crdt
+
+import (
+	"encoding/json"
+	"maps"
+
+	"gossip-glomers/internal/hlc"
+)
+
+// LWWMap is a map of independent last-writer-wins registers. Deletes are kept
+// as timestamped tombstones so a stale write cannot resurrect the key.
+type LWWMap[K comparable, V any] map[K]LWWRegister[V]
+
+func (m LWWMap[K, V]) Set(key K, value V, ts hlc.Timestamp) {
+	reg := m[key]
+	reg.Set(value, ts)
+	m[key] = reg
+}
+
+func (m LWWMap[K, V]) Delete(key K, ts hlc.Timestamp) {
+	reg := m[key]
+	reg.Delete(ts)
+	m[key] = reg
+}
+
+func (m LWWMap[K, V]) Get(key K) (V, bool) {
+	reg := m[key]
+	return reg.Get()
+}
+
+func (m LWWMap[K, V]) Keys() []K {
+	keys := make([]K, 0, len(m))
+	for key, reg := range m {
+		if _, ok := reg.Get(); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (m LWWMap[K, V]) Merge(other LWWMap[K, V]) {
+	for key, remote := range other {
+		reg := m[key]
+		reg.Merge(&remote)
+		m[key] = reg
+	}
+}
+
+func (m LWWMap[K, V]) Copy() LWWMap[K, V] {
+	return maps.Clone(m)
+}
+
+func (m LWWMap[K, V]) Equal(other LWWMap[K, V]) bool {
+	return maps.EqualFunc(m, other, func(a, b LWWRegister[V]) bool { return a.Equal(&b) })
+}
+
+func (m LWWMap[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[K]LWWRegister[V](m))
+}
+
+func (m *LWWMap[K, V]) UnmarshalJSON(data []byte) error {
+	var regs map[K]LWWRegister[V]
+	if err := json.Unmarshal(data, &regs); err != nil {
+		return err
+	}
+	*m = regs
+	return nil
+}
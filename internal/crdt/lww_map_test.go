@@ -0,0 +1,123 @@
+package crdt
+
+import (
+	"slices"
+	"testing"
+)
+
+func sortedKeys(m LWWMap[string, int]) []string {
+	got := m.Keys()
+	slices.Sort(got)
+	return got
+}
+
+func TestLWWMap_SetDelete(t *testing.T) {
+	m := LWWMap[string, int]{}
+	m.Set("a", 1, at(1, "n0"))
+	m.Set("b", 2, at(2, "n0"))
+	m.Set("a", 3, at(3, "n0"))
+	m.Delete("b", at(4, "n0"))
+	m.Set("b", 5, at(3, "n1"))
+
+	if got, ok := m.Get("a"); got != 3 || !ok {
+		t.Errorf("Get(a) = (%d, %v), want (3, true)", got, ok)
+	}
+	if got, ok := m.Get("b"); ok {
+		t.Errorf("Get(b) = (%d, %v), want deleted", got, ok)
+	}
+	if got, ok := m.Get("c"); ok {
+		t.Errorf("Get(c) = (%d, %v), want missing", got, ok)
+	}
+	if got := sortedKeys(m); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("Keys() = %v, want [a]", got)
+	}
+}
+
+func TestLWWMap_Merge(t *testing.T) {
+	tests := []struct {
+		name  string
+		m     LWWMap[string, int]
+		other LWWMap[string, int]
+		want  map[string]int
+	}{
+		{
+			name:  "merge disjoint keys",
+			m:     LWWMap[string, int]{"a": {Value: 1, Timestamp: at(1, "n0")}},
+			other: LWWMap[string, int]{"b": {Value: 2, Timestamp: at(1, "n1")}},
+			want:  map[string]int{"a": 1, "b": 2},
+		},
+		{
+			name:  "newer remote value wins",
+			m:     LWWMap[string, int]{"a": {Value: 1, Timestamp: at(1, "n0")}},
+			other: LWWMap[string, int]{"a": {Value: 2, Timestamp: at(2, "n1")}},
+			want:  map[string]int{"a": 2},
+		},
+		{
+			name:  "newer local value wins",
+			m:     LWWMap[string, int]{"a": {Value: 1, Timestamp: at(3, "n0")}},
+			other: LWWMap[string, int]{"a": {Value: 2, Timestamp: at(2, "n1")}},
+			want:  map[string]int{"a": 1},
+		},
+		{
+			name:  "newer remote delete wins",
+			m:     LWWMap[string, int]{"a": {Value: 1, Timestamp: at(1, "n0")}},
+			other: LWWMap[string, int]{"a": {Timestamp: at(2, "n1"), Deleted: true}},
+			want:  map[string]int{},
+		},
+		{
+			name:  "stale remote value does not resurrect deleted key",
+			m:     LWWMap[string, int]{"a": {Timestamp: at(2, "n0"), Deleted: true}},
+			other: LWWMap[string, int]{"a": {Value: 1, Timestamp: at(1, "n1")}},
+			want:  map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.m.Merge(tt.other)
+			got := make(map[string]int)
+			for _, key := range tt.m.Keys() {
+				got[key], _ = tt.m.Get(key)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("after Merge = %v, want %v", got, tt.want)
+			}
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("after Merge = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// Commutativity: merge(a,b) and merge(b,a) hold the same entries
+func TestLWWMap_Merge_Commutative(t *testing.T) {
+	a := LWWMap[string, int]{}
+	a.Set("x", 1, at(1, "n0"))
+	a.Set("y", 2, at(3, "n0"))
+	b := LWWMap[string, int]{}
+	b.Set("x", 3, at(2, "n1"))
+	b.Delete("y", at(3, "n1"))
+
+	ab := a.Copy()
+	ab.Merge(b)
+	ba := b.Copy()
+	ba.Merge(a)
+
+	for _, key := range []string{"x", "y"} {
+		if ab[key] != ba[key] {
+			t.Errorf("commutativity violated on %q: merge(a,b)=%+v, merge(b,a)=%+v", key, ab[key], ba[key])
+		}
+	}
+}
+
+func TestLWWMap_Copy(t *testing.T) {
+	m := LWWMap[string, int]{}
+	m.Set("a", 1, at(1, "n0"))
+	cpy := m.Copy()
+	m.Set("a", 2, at(2, "n0"))
+
+	if got, _ := cpy.Get("a"); got != 1 {
+		t.Errorf("Copy() was affected by mutation of original: got %d, want 1", got)
+	}
+}
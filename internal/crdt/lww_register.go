@@ -0,0 +1,65 @@
+package crdt
+
+import (
+	"encoding/json"
+
+	"gossip-glomers/internal/hlc"
+)
+
+// LWWRegister holds the value written with the greatest hybrid logical
+// timestamp. Concurrent writes are resolved by timestamp, so one of them is
+// silently dropped.
+type LWWRegister[T any] struct {
+	Value     T             `json:"value"`
+	Timestamp hlc.Timestamp `json:"timestamp"`
+	Deleted   bool          `json:"deleted,omitempty"`
+}
+
+// Set writes value unless the register already holds a newer write.
+func (r *LWWRegister[T]) Set(value T, ts hlc.Timestamp) {
+	r.Merge(&LWWRegister[T]{Value: value, Timestamp: ts})
+}
+
+// Delete clears the register unless it already holds a newer write.
+func (r *LWWRegister[T]) Delete(ts hlc.Timestamp) {
+	r.Merge(&LWWRegister[T]{Timestamp: ts, Deleted: true})
+}
+
+func (r *LWWRegister[T]) Get() (T, bool) {
+	if r.Timestamp.IsZero() || r.Deleted {
+		var zero T
+		return zero, false
+	}
+	return r.Value, true
+}
+
+func (r *LWWRegister[T]) Merge(other *LWWRegister[T]) {
+	if r.Timestamp.Less(other.Timestamp) {
+		*r = *other
+	}
+}
+
+func (r *LWWRegister[T]) Copy() *LWWRegister[T] {
+	cpy := *r
+	return &cpy
+}
+
+// Equal compares writes by timestamp: every write carries a unique one.
+func (r *LWWRegister[T]) Equal(other *LWWRegister[T]) bool {
+	return r.Timestamp == other.Timestamp && r.Deleted == other.Deleted
+}
+
+func (r *LWWRegister[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(lwwRegisterJSON[T](*r))
+}
+
+func (r *LWWRegister[T]) UnmarshalJSON(data []byte) error {
+	var fields lwwRegisterJSON[T]
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*r = LWWRegister[T](fields)
+	return nil
+}
+
+type lwwRegisterJSON[T any] LWWRegister[T]
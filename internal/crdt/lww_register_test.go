@@ -0,0 +1,148 @@
+package crdt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gossip-glomers/internal/hlc"
+)
+
+func at(wall int64, node string) hlc.Timestamp {
+	return hlc.Timestamp{Wall: wall, Node: node}
+}
+
+func TestLWWRegister_Set(t *testing.T) {
+	type write struct {
+		value  string
+		ts     hlc.Timestamp
+		delete bool
+	}
+	tests := []struct {
+		name   string
+		writes []write
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "empty register",
+			want:   "",
+			wantOk: false,
+		},
+		{
+			name:   "single write",
+			writes: []write{{"a", at(1, "n0"), false}},
+			want:   "a",
+			wantOk: true,
+		},
+		{
+			name:   "later write wins",
+			writes: []write{{"a", at(1, "n0"), false}, {"b", at(2, "n0"), false}},
+			want:   "b",
+			wantOk: true,
+		},
+		{
+			name:   "stale write is ignored",
+			writes: []write{{"b", at(2, "n0"), false}, {"a", at(1, "n0"), false}},
+			want:   "b",
+			wantOk: true,
+		},
+		{
+			name:   "node breaks timestamp tie",
+			writes: []write{{"b", at(1, "n1"), false}, {"a", at(1, "n0"), false}},
+			want:   "b",
+			wantOk: true,
+		},
+		{
+			name:   "delete clears value",
+			writes: []write{{"a", at(1, "n0"), false}, {"", at(2, "n0"), true}},
+			want:   "",
+			wantOk: false,
+		},
+		{
+			name:   "write after delete restores value",
+			writes: []write{{"a", at(1, "n0"), false}, {"", at(2, "n0"), true}, {"c", at(3, "n1"), false}},
+			want:   "c",
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r LWWRegister[string]
+			for _, w := range tt.writes {
+				if w.delete {
+					r.Delete(w.ts)
+				} else {
+					r.Set(w.value, w.ts)
+				}
+			}
+			got, ok := r.Get()
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Get() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+// Commutativity: merge(a,b) and merge(b,a) hold the same value
+func TestLWWRegister_Merge_Commutative(t *testing.T) {
+	fake := time.Unix(1000, 0)
+	now := func() time.Time { return fake }
+	c0 := hlc.NewClock("n0", now)
+	c1 := hlc.NewClock("n1", now)
+
+	var a, b LWWRegister[int]
+	a.Set(1, c0.Now())
+	b.Set(2, c1.Now())
+
+	ab := a.Copy()
+	ab.Merge(&b)
+	ba := b.Copy()
+	ba.Merge(&a)
+
+	gotAB, _ := ab.Get()
+	gotBA, _ := ba.Get()
+	if gotAB != gotBA {
+		t.Errorf("commutativity violated: merge(a,b)=%d, merge(b,a)=%d", gotAB, gotBA)
+	}
+	if gotAB != 2 {
+		t.Errorf("same wall time must be resolved by node: got %d, want 2", gotAB)
+	}
+}
+
+// Causality: a write issued after observing another always wins, even under clock skew
+func TestLWWRegister_Merge_CausalWriteWins(t *testing.T) {
+	ahead := time.Unix(2000, 0)
+	behind := time.Unix(1000, 0)
+	c0 := hlc.NewClock("n0", func() time.Time { return ahead })
+	c1 := hlc.NewClock("n1", func() time.Time { return behind })
+
+	var a LWWRegister[string]
+	a.Set("from n0", c0.Now())
+
+	b := a.Copy()
+	c1.Update(b.Timestamp)
+	b.Set("from n1", c1.Now())
+
+	a.Merge(b)
+	if got, _ := a.Get(); got != "from n1" {
+		t.Errorf("Get() after merge = %q, want %q", got, "from n1")
+	}
+}
+
+func TestLWWRegister_JSON(t *testing.T) {
+	var r LWWRegister[string]
+	r.Set("a", at(5, "n0"))
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got LWWRegister[string]
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got != r {
+		t.Errorf("round trip = %+v, want %+v", got, r)
+	}
+}
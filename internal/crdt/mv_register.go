@@ -0,0 +1,107 @@
+package crdt
+
+import "encoding/json"
+
+type MVEntry[T any] struct {
+	Value   T             `json:"value"`
+	Version VersionVector `json:"version"`
+}
+
+// MVRegister is a multi-value register: a write replaces every value it has
+// observed, while causally concurrent writes are all kept until a later write
+// supersedes them.
+type MVRegister[T any] struct {
+	Entries []MVEntry[T] `json:"entries"`
+}
+
+func (r *MVRegister[T]) Set(node string, value T) {
+	version := r.Version()
+	version.Increment(node)
+	r.Entries = []MVEntry[T]{{Value: value, Version: version}}
+}
+
+// Values returns every concurrently written value, or nothing if the register
+// was never written.
+func (r *MVRegister[T]) Values() []T {
+	values := make([]T, 0, len(r.Entries))
+	for _, entry := range r.Entries {
+		values = append(values, entry.Value)
+	}
+	return values
+}
+
+// Version returns the causal context of the register: every write it has seen.
+func (r *MVRegister[T]) Version() VersionVector {
+	version := make(VersionVector)
+	for _, entry := range r.Entries {
+		version.Merge(entry.Version)
+	}
+	return version
+}
+
+func (r *MVRegister[T]) Merge(other *MVRegister[T]) {
+	var merged []MVEntry[T]
+	keep := func(entry MVEntry[T], rivals []MVEntry[T]) {
+		for _, rival := range rivals {
+			if entry.Version.Compare(rival.Version) == Before {
+				return
+			}
+		}
+		for _, kept := range merged {
+			if entry.Version.Compare(kept.Version) == Equal {
+				return
+			}
+		}
+		merged = append(merged, entry)
+	}
+	for _, entry := range r.Entries {
+		keep(entry, other.Entries)
+	}
+	for _, entry := range other.Entries {
+		keep(entry, r.Entries)
+	}
+	r.Entries = merged
+}
+
+func (r *MVRegister[T]) Copy() *MVRegister[T] {
+	cpy := &MVRegister[T]{Entries: make([]MVEntry[T], 0, len(r.Entries))}
+	for _, entry := range r.Entries {
+		cpy.Entries = append(cpy.Entries, MVEntry[T]{Value: entry.Value, Version: entry.Version.Copy()})
+	}
+	return cpy
+}
+
+// Equal compares the sets of versions held: every write carries a unique one.
+func (r *MVRegister[T]) Equal(other *MVRegister[T]) bool {
+	if len(r.Entries) != len(other.Entries) {
+		return false
+	}
+	for _, entry := range r.Entries {
+		found := false
+		for _, rival := range other.Entries {
+			if entry.Version.Equal(rival.Version) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *MVRegister[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mvRegisterJSON[T](*r))
+}
+
+func (r *MVRegister[T]) UnmarshalJSON(data []byte) error {
+	var fields mvRegisterJSON[T]
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*r = MVRegister[T](fields)
+	return nil
+}
+
+type mvRegisterJSON[T any] MVRegister[T]
@@ -0,0 +1,137 @@
+package crdt
+
+import (
+	"slices"
+	"testing"
+)
+
+func sortedValues(r *MVRegister[string]) []string {
+	got := r.Values()
+	slices.Sort(got)
+	return got
+}
+
+func TestMVRegister_Set(t *testing.T) {
+	r := &MVRegister[string]{}
+	if got := r.Values(); len(got) != 0 {
+		t.Errorf("Values() of empty register = %v, want []", got)
+	}
+
+	r.Set("n0", "a")
+	r.Set("n1", "b")
+	if got := sortedValues(r); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("Values() = %v, want [b]", got)
+	}
+	if got := r.Version().Compare(VersionVector{"n0": 1, "n1": 1}); got != Equal {
+		t.Errorf("Version() = %v, want n0:1 n1:1", r.Version())
+	}
+}
+
+func TestMVRegister_Merge(t *testing.T) {
+	base := &MVRegister[string]{}
+	base.Set("n0", "a")
+
+	tests := []struct {
+		name  string
+		left  func() *MVRegister[string]
+		right func() *MVRegister[string]
+		want  []string
+	}{
+		{
+			name: "later write overwrites observed one",
+			left: func() *MVRegister[string] { return base.Copy() },
+			right: func() *MVRegister[string] {
+				r := base.Copy()
+				r.Set("n1", "b")
+				return r
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "concurrent writes are both kept",
+			left: func() *MVRegister[string] {
+				r := base.Copy()
+				r.Set("n0", "b")
+				return r
+			},
+			right: func() *MVRegister[string] {
+				r := base.Copy()
+				r.Set("n1", "c")
+				return r
+			},
+			want: []string{"b", "c"},
+		},
+		{
+			name:  "identical states are deduplicated",
+			left:  func() *MVRegister[string] { return base.Copy() },
+			right: func() *MVRegister[string] { return base.Copy() },
+			want:  []string{"a"},
+		},
+		{
+			name:  "merge into empty",
+			left:  func() *MVRegister[string] { return &MVRegister[string]{} },
+			right: func() *MVRegister[string] { return base.Copy() },
+			want:  []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right := tt.left(), tt.right()
+			left.Merge(right)
+			if got := sortedValues(left); !slices.Equal(got, tt.want) {
+				t.Errorf("Values() after Merge = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// A write after merging concurrent values supersedes all of them
+func TestMVRegister_Set_ResolvesConflict(t *testing.T) {
+	a := &MVRegister[string]{}
+	a.Set("n0", "a")
+	b := &MVRegister[string]{}
+	b.Set("n1", "b")
+
+	a.Merge(b)
+	a.Set("n0", "resolved")
+
+	stale := b.Copy()
+	stale.Merge(a)
+	if got := sortedValues(stale); !slices.Equal(got, []string{"resolved"}) {
+		t.Errorf("Values() = %v, want [resolved]", got)
+	}
+}
+
+// Commutativity: merge(a,b) and merge(b,a) hold the same values
+func TestMVRegister_Merge_Commutative(t *testing.T) {
+	a := &MVRegister[string]{}
+	a.Set("n0", "a")
+	b := &MVRegister[string]{}
+	b.Set("n1", "b")
+	c := b.Copy()
+	c.Set("n2", "c")
+	a.Merge(b)
+
+	ac := a.Copy()
+	ac.Merge(c)
+	ca := c.Copy()
+	ca.Merge(a)
+
+	if gotAC, gotCA := sortedValues(ac), sortedValues(ca); !slices.Equal(gotAC, gotCA) {
+		t.Errorf("commutativity violated: merge(a,c)=%v, merge(c,a)=%v", gotAC, gotCA)
+	}
+}
+
+func TestMVRegister_Copy(t *testing.T) {
+	r := &MVRegister[string]{}
+	r.Set("n0", "a")
+	cpy := r.Copy()
+	r.Set("n0", "b")
+
+	if got := sortedValues(cpy); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("Copy() was affected by mutation of original: got %v", got)
+	}
+	if got := cpy.Version()["n0"]; got != 1 {
+		t.Errorf("Copy() version was affected by mutation of original: got %d, want 1", got)
+	}
+}
@@ -0,0 +1,135 @@
+package crdt
+
+import (
+	"encoding/json"
+	"maps"
+)
+
+// ORMap is an add-wins observed-remove map whose values are themselves
+// CRDTs. Keys are tracked in an ORSet; every Update replaces the tags of the
+// key it has observed with a fresh one and stores the updated value under
+// that tag. A key's value is the merge of the values under its live tags, so
+// concurrent updates are merged recursively, an update concurrent with a
+// Remove survives it, and a key that is removed and added again starts from
+// an empty value instead of resurrecting state it was removed with.
+type ORMap[K comparable, V StateCRDT[V]] struct {
+	Entries ORSet[K]  `json:"entries"`
+	Values  map[Dot]V `json:"values"`
+	empty   func() V
+}
+
+// NewORMap returns an empty map whose new keys start out as empty().
+func NewORMap[K comparable, V StateCRDT[V]](empty func() V) ORMap[K, V] {
+	return ORMap[K, V]{
+		Entries: NewORSet[K](),
+		Values:  make(map[Dot]V),
+		empty:   empty,
+	}
+}
+
+// Update applies fn to the value of key at node, creating the key if needed.
+// fn mutates the value it is given in place.
+func (m *ORMap[K, V]) Update(node string, key K, fn func(V)) {
+	val, ok := m.Get(key)
+	if !ok {
+		val = m.empty()
+	}
+	fn(val)
+	m.Entries.Remove(node, key)
+	m.Entries.Add(node, key)
+	m.prune()
+	for tag := range m.Entries.Entries[key] {
+		m.Values[tag] = val
+	}
+}
+
+// Get returns a copy of the value of key, merged from all its live tags.
+func (m *ORMap[K, V]) Get(key K) (V, bool) {
+	var val V
+	found := false
+	for tag := range m.Entries.Entries[key] {
+		if !found {
+			val, found = m.Values[tag].Copy(), true
+		} else {
+			val.Merge(m.Values[tag])
+		}
+	}
+	return val, found
+}
+
+func (m *ORMap[K, V]) Remove(node string, key K) {
+	m.Entries.Remove(node, key)
+	m.prune()
+}
+
+func (m *ORMap[K, V]) Contains(key K) bool {
+	return m.Entries.Contains(key)
+}
+
+func (m *ORMap[K, V]) Keys() []K {
+	return m.Entries.Elements()
+}
+
+func (m *ORMap[K, V]) Merge(other *ORMap[K, V]) {
+	m.Entries.Merge(other.Entries)
+	for tag, val := range other.Values {
+		if cur, ok := m.Values[tag]; ok {
+			cur.Merge(val)
+		} else {
+			m.Values[tag] = val.Copy()
+		}
+	}
+	m.prune()
+}
+
+// prune drops the values stored under tags that are no longer live. A removed
+// tag never becomes live again, so this cannot lose state a merge needs.
+func (m *ORMap[K, V]) prune() {
+	live := make(map[Dot]struct{}, len(m.Values))
+	for _, tags := range m.Entries.Entries {
+		maps.Copy(live, tags)
+	}
+	maps.DeleteFunc(m.Values, func(tag Dot, _ V) bool {
+		_, ok := live[tag]
+		return !ok
+	})
+}
+
+// Compact drops the tombstones of stable removes and updates, see
+// ORSet.Compact.
+func (m *ORMap[K, V]) Compact(stable VersionVector) {
+	m.Entries.Compact(stable)
+}
+
+func (m *ORMap[K, V]) Copy() *ORMap[K, V] {
+	cpy := NewORMap[K](m.empty)
+	cpy.Entries = m.Entries.Copy()
+	for tag, val := range m.Values {
+		cpy.Values[tag] = val.Copy()
+	}
+	return &cpy
+}
+
+func (m *ORMap[K, V]) Equal(other *ORMap[K, V]) bool {
+	return m.Entries.Equal(other.Entries) && maps.EqualFunc(m.Values, other.Values, V.Equal)
+}
+
+func (m *ORMap[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(orMapJSON[K, V]{Entries: m.Entries, Values: m.Values})
+}
+
+// UnmarshalJSON keeps the empty value constructor of m, so decode into a map
+// created by NewORMap if it is going to be updated rather than merged.
+func (m *ORMap[K, V]) UnmarshalJSON(data []byte) error {
+	fields := orMapJSON[K, V]{Entries: NewORSet[K](), Values: make(map[Dot]V)}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	m.Entries, m.Values = fields.Entries, fields.Values
+	return nil
+}
+
+type orMapJSON[K comparable, V StateCRDT[V]] struct {
+	Entries ORSet[K]  `json:"entries"`
+	Values  map[Dot]V `json:"values"`
+}
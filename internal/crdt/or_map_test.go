@@ -0,0 +1,230 @@
+package crdt
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+)
+
+type counterMap = ORMap[string, PNCounter[string, int]]
+
+func newCounterMap() *counterMap {
+	m := NewORMap[string](NewPNCounter[string, int])
+	return &m
+}
+
+func addTo(m *counterMap, node, key string, delta int) {
+	m.Update(node, key, func(c PNCounter[string, int]) { c.Increment(node, delta) })
+}
+
+func counterValues(m *counterMap) map[string]int {
+	values := make(map[string]int)
+	for _, key := range m.Keys() {
+		c, _ := m.Get(key)
+		values[key] = c.Value()
+	}
+	return values
+}
+
+func TestORMap_Update(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []struct {
+			node, key string
+			delta     int
+		}
+		remove []string
+		want   map[string]int
+	}{
+		{
+			name: "empty map",
+			want: map[string]int{},
+		},
+		{
+			name: "single key",
+			ops: []struct {
+				node, key string
+				delta     int
+			}{{"n0", "a", 3}, {"n0", "a", -1}},
+			want: map[string]int{"a": 2},
+		},
+		{
+			name: "several keys and nodes",
+			ops: []struct {
+				node, key string
+				delta     int
+			}{{"n0", "a", 3}, {"n1", "b", 5}, {"n1", "a", 1}},
+			want: map[string]int{"a": 4, "b": 5},
+		},
+		{
+			name: "remove key",
+			ops: []struct {
+				node, key string
+				delta     int
+			}{{"n0", "a", 3}, {"n0", "b", 5}},
+			remove: []string{"b"},
+			want:   map[string]int{"a": 3},
+		},
+		{
+			name:   "remove unknown key is no-op",
+			remove: []string{"a"},
+			want:   map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newCounterMap()
+			for _, op := range tt.ops {
+				addTo(m, op.node, op.key, op.delta)
+			}
+			for _, key := range tt.remove {
+				m.Remove("n0", key)
+			}
+			if got := counterValues(m); !maps.Equal(got, tt.want) {
+				t.Errorf("values = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Concurrent updates of the same key merge the nested counters
+func TestORMap_Merge_Recursive(t *testing.T) {
+	a, b := newCounterMap(), newCounterMap()
+	addTo(a, "n0", "a", 3)
+	addTo(b, "n1", "a", 4)
+	addTo(b, "n1", "b", 1)
+
+	a.Merge(b)
+	if got, want := counterValues(a), (map[string]int{"a": 7, "b": 1}); !maps.Equal(got, want) {
+		t.Errorf("values after Merge = %v, want %v", got, want)
+	}
+
+	// The next local update starts from the merged value
+	addTo(a, "n0", "a", 1)
+	if got := counterValues(a)["a"]; got != 8 {
+		t.Errorf(`value of "a" = %d, want 8`, got)
+	}
+}
+
+// Add wins: an update concurrent with a remove keeps the key, with the state
+// the updating replica had observed
+func TestORMap_Merge_AddWins(t *testing.T) {
+	base := newCounterMap()
+	addTo(base, "n0", "a", 3)
+
+	a := base.Copy()
+	a.Remove("n0", "a")
+
+	b := base.Copy()
+	addTo(b, "n1", "a", 1)
+
+	ab := a.Copy()
+	ab.Merge(b)
+	ba := b.Copy()
+	ba.Merge(a)
+
+	want := map[string]int{"a": 4}
+	if got := counterValues(ab); !maps.Equal(got, want) {
+		t.Errorf("merge(a,b) values = %v, want %v", got, want)
+	}
+	if got := counterValues(ba); !maps.Equal(got, want) {
+		t.Errorf("merge(b,a) values = %v, want %v", got, want)
+	}
+}
+
+// A key removed and added again starts empty, even after merging a replica
+// that still holds the old value
+func TestORMap_Remove_Resets(t *testing.T) {
+	a := newCounterMap()
+	addTo(a, "n0", "a", 3)
+	stale := a.Copy()
+
+	a.Remove("n0", "a")
+	addTo(a, "n0", "a", 1)
+	a.Merge(stale)
+
+	if got := counterValues(a)["a"]; got != 1 {
+		t.Errorf(`value of "a" = %d, want 1`, got)
+	}
+	if got := len(a.Values); got != 1 {
+		t.Errorf("len(Values) = %d, want 1: values of removed tags are kept", got)
+	}
+}
+
+func TestORMap_NestedTypes(t *testing.T) {
+	sets := NewORMap[string](func() GSet[int] { return GSet[int]{} })
+	sets.Update("n0", "a", func(s GSet[int]) { s.Add(1) })
+	other := sets.Copy()
+	other.Update("n1", "a", func(s GSet[int]) { s.Add(2) })
+	sets.Update("n0", "a", func(s GSet[int]) { s.Add(3) })
+	sets.Merge(other)
+	if got, _ := sets.Get("a"); !slices.Equal(sortedSet(got), []int{1, 2, 3}) {
+		t.Errorf(`Get("a") = %v, want [1 2 3]`, sortedSet(got))
+	}
+
+	registers := NewORMap[string](func() *LWWRegister[string] { return &LWWRegister[string]{} })
+	registers.Update("n0", "a", func(r *LWWRegister[string]) { r.Set("old", at(1, "n0")) })
+	other2 := registers.Copy()
+	other2.Update("n1", "a", func(r *LWWRegister[string]) { r.Set("new", at(2, "n1")) })
+	registers.Merge(other2)
+	if got, _ := registers.Get("a"); got == nil {
+		t.Fatalf(`Get("a") missing`)
+	} else if v, _ := got.Get(); v != "new" {
+		t.Errorf(`Get("a") = %q, want "new"`, v)
+	}
+}
+
+func TestORMap_Get_ReturnsCopy(t *testing.T) {
+	m := newCounterMap()
+	addTo(m, "n0", "a", 3)
+	c, _ := m.Get("a")
+	c.Increment("n0", 10)
+	if got := counterValues(m)["a"]; got != 3 {
+		t.Errorf(`value of "a" = %d after mutating Get result, want 3`, got)
+	}
+	if _, ok := m.Get("b"); ok {
+		t.Errorf(`Get("b") ok = true, want false`)
+	}
+}
+
+func TestORMap_Copy(t *testing.T) {
+	m := newCounterMap()
+	addTo(m, "n0", "a", 3)
+	cpy := m.Copy()
+	addTo(m, "n0", "a", 1)
+	m.Remove("n0", "a")
+	addTo(cpy, "n1", "b", 2)
+
+	if got, want := counterValues(cpy), (map[string]int{"a": 3, "b": 2}); !maps.Equal(got, want) {
+		t.Errorf("Copy() was affected by mutation of original: got %v, want %v", got, want)
+	}
+	if got := counterValues(m); len(got) != 0 {
+		t.Errorf("Original was affected by mutation of copy: got %v", got)
+	}
+}
+
+func TestORMap_JSON(t *testing.T) {
+	m := newCounterMap()
+	addTo(m, "n0", "a", 3)
+	addTo(m, "n1", "b", -2)
+	m.Remove("n0", "b")
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got := newCounterMap()
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !got.Equal(m) {
+		t.Errorf("round trip = %s, want equal state", data)
+	}
+
+	// The decoded map can still create new keys
+	addTo(got, "n0", "c", 1)
+	if got, want := counterValues(got), (map[string]int{"a": 3, "c": 1}); !maps.Equal(got, want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+}
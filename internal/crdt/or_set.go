@@ -0,0 +1,143 @@
+package crdt
+
+import (
+	"encoding/json"
+	"maps"
+)
+
+// ORSet is an add-wins observed-remove set. Every Add tags the element with a
+// fresh dot, Remove tombstones only the tags it has observed, so an Add that is
+// concurrent with a Remove survives the merge.
+type ORSet[T comparable] struct {
+	Clock   VersionVector          `json:"clock"`
+	Entries map[T]map[Dot]struct{} `json:"entries"`
+	Removed map[Dot]Dot            `json:"removed"`
+}
+
+func NewORSet[T comparable]() ORSet[T] {
+	return ORSet[T]{
+		Clock:   make(VersionVector),
+		Entries: make(map[T]map[Dot]struct{}),
+		Removed: make(map[Dot]Dot),
+	}
+}
+
+func (c ORSet[T]) Add(node string, element T) {
+	dot := c.Clock.Increment(node)
+	if c.Entries[element] == nil {
+		c.Entries[element] = make(map[Dot]struct{})
+	}
+	c.Entries[element][dot] = struct{}{}
+}
+
+func (c ORSet[T]) Remove(node string, element T) {
+	tags, ok := c.Entries[element]
+	if !ok {
+		return
+	}
+	dot := c.Clock.Increment(node)
+	for tag := range tags {
+		c.Removed[tag] = dot
+	}
+	delete(c.Entries, element)
+}
+
+func (c ORSet[T]) Contains(element T) bool {
+	_, ok := c.Entries[element]
+	return ok
+}
+
+func (c ORSet[T]) Elements() []T {
+	elements := make([]T, 0, len(c.Entries))
+	for k := range c.Entries {
+		elements = append(elements, k)
+	}
+	return elements
+}
+
+func (c ORSet[T]) Merge(other ORSet[T]) {
+	// A tag a side has seen but holds neither as an entry nor as a tombstone
+	// was removed there and its tombstone compacted away
+	for element, tags := range c.Entries {
+		for tag := range tags {
+			if other.Clock.Contains(tag) && !other.hasTag(element, tag) {
+				delete(tags, tag)
+			}
+		}
+	}
+	for element, tags := range other.Entries {
+		for tag := range tags {
+			if c.Clock.Contains(tag) && !c.hasTag(element, tag) {
+				continue
+			}
+			if c.Entries[element] == nil {
+				c.Entries[element] = make(map[Dot]struct{})
+			}
+			c.Entries[element][tag] = struct{}{}
+		}
+	}
+	c.Clock.Merge(other.Clock)
+	for tag, dot := range other.Removed {
+		if cur, ok := c.Removed[tag]; !ok || cur.Less(dot) {
+			c.Removed[tag] = dot
+		}
+	}
+	for element, tags := range c.Entries {
+		for tag := range tags {
+			if _, removed := c.Removed[tag]; removed {
+				delete(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			delete(c.Entries, element)
+		}
+	}
+}
+
+func (c ORSet[T]) hasTag(element T, tag Dot) bool {
+	_, added := c.Entries[element][tag]
+	_, removed := c.Removed[tag]
+	return added || removed
+}
+
+// Compact drops the tombstones of removes contained in stable, the events
+// every replica has seen (see StabilityTracker). Merge still recognises the
+// removed tags through the clock, so stale states cannot resurrect them.
+func (c ORSet[T]) Compact(stable VersionVector) {
+	maps.DeleteFunc(c.Removed, func(_, dot Dot) bool {
+		return stable.Contains(dot)
+	})
+}
+
+func (c ORSet[T]) Copy() ORSet[T] {
+	cpy := NewORSet[T]()
+	maps.Copy(cpy.Clock, c.Clock)
+	maps.Copy(cpy.Removed, c.Removed)
+	for element, tags := range c.Entries {
+		cpy.Entries[element] = maps.Clone(tags)
+	}
+	return cpy
+}
+
+func (c ORSet[T]) Equal(other ORSet[T]) bool {
+	return c.Clock.Equal(other.Clock) &&
+		maps.Equal(c.Removed, other.Removed) &&
+		maps.EqualFunc(c.Entries, other.Entries, maps.Equal)
+}
+
+func (c ORSet[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(orSetJSON[T](c))
+}
+
+func (c *ORSet[T]) UnmarshalJSON(data []byte) error {
+	fields := orSetJSON[T](NewORSet[T]())
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*c = ORSet[T](fields)
+	return nil
+}
+
+// orSetJSON has the fields of ORSet but none of its methods, so encoding/json
+// handles it without recursing into MarshalJSON.
+type orSetJSON[T comparable] ORSet[T]
@@ -0,0 +1,274 @@
+package crdt
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+type orSetOp struct {
+	node    string
+	remove  bool
+	element int
+}
+
+func applyORSetOps(c ORSet[int], ops []orSetOp) {
+	for _, op := range ops {
+		if op.remove {
+			c.Remove(op.node, op.element)
+		} else {
+			c.Add(op.node, op.element)
+		}
+	}
+}
+
+func sortedElements(c ORSet[int]) []int {
+	got := c.Elements()
+	slices.Sort(got)
+	return got
+}
+
+func TestORSet_AddRemove(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []orSetOp
+		want []int
+	}{
+		{
+			name: "add to empty set",
+			ops:  []orSetOp{{"n0", false, 1}},
+			want: []int{1},
+		},
+		{
+			name: "add duplicate — no growth",
+			ops:  []orSetOp{{"n0", false, 1}, {"n0", false, 1}},
+			want: []int{1},
+		},
+		{
+			name: "remove added element",
+			ops:  []orSetOp{{"n0", false, 1}, {"n0", false, 2}, {"n0", true, 1}},
+			want: []int{2},
+		},
+		{
+			name: "remove unknown element is no-op",
+			ops:  []orSetOp{{"n0", false, 1}, {"n0", true, 2}},
+			want: []int{1},
+		},
+		{
+			name: "re-add after remove",
+			ops:  []orSetOp{{"n0", false, 1}, {"n1", true, 1}, {"n1", false, 1}},
+			want: []int{1},
+		},
+		{
+			name: "remove every tag of an element",
+			ops:  []orSetOp{{"n0", false, 1}, {"n1", false, 1}, {"n0", true, 1}},
+			want: []int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewORSet[int]()
+			applyORSetOps(c, tt.ops)
+			if got := sortedElements(c); !slices.Equal(got, tt.want) {
+				t.Errorf("Elements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestORSet_Contains(t *testing.T) {
+	c := NewORSet[int]()
+	c.Add("n0", 1)
+	c.Add("n0", 2)
+	c.Remove("n0", 2)
+
+	if !c.Contains(1) {
+		t.Errorf("Contains(1) = false, want true")
+	}
+	if c.Contains(2) {
+		t.Errorf("Contains(2) = true, want false")
+	}
+	if c.Contains(3) {
+		t.Errorf("Contains(3) = true, want false")
+	}
+}
+
+func TestORSet_Merge(t *testing.T) {
+	tests := []struct {
+		name  string
+		c     []orSetOp
+		other []orSetOp
+		want  []int
+	}{
+		{
+			name:  "merge disjoint sets",
+			c:     []orSetOp{{"n0", false, 1}},
+			other: []orSetOp{{"n1", false, 2}},
+			want:  []int{1, 2},
+		},
+		{
+			name:  "merge overlapping sets",
+			c:     []orSetOp{{"n0", false, 1}, {"n0", false, 2}},
+			other: []orSetOp{{"n1", false, 2}, {"n1", false, 3}},
+			want:  []int{1, 2, 3},
+		},
+		{
+			name:  "merge with empty",
+			c:     []orSetOp{{"n0", false, 1}},
+			other: nil,
+			want:  []int{1},
+		},
+		{
+			name:  "merge into empty",
+			c:     nil,
+			other: []orSetOp{{"n1", false, 1}, {"n1", false, 2}},
+			want:  []int{1, 2},
+		},
+		{
+			name:  "remote remove of unobserved add does not apply",
+			c:     []orSetOp{{"n0", false, 1}},
+			other: []orSetOp{{"n1", false, 1}, {"n1", true, 1}},
+			want:  []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, other := NewORSet[int](), NewORSet[int]()
+			applyORSetOps(c, tt.c)
+			applyORSetOps(other, tt.other)
+			c.Merge(other)
+			if got := sortedElements(c); !slices.Equal(got, tt.want) {
+				t.Errorf("Elements() after Merge = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Observed remove: a remove propagates to replicas that saw the same add
+func TestORSet_Merge_ObservedRemove(t *testing.T) {
+	a := NewORSet[int]()
+	a.Add("n0", 1)
+
+	b := a.Copy()
+	b.Remove("n1", 1)
+
+	a.Merge(b)
+	if a.Contains(1) {
+		t.Errorf("observed remove lost: Elements() = %v", a.Elements())
+	}
+}
+
+// Add wins: an add concurrent with a remove survives the merge in both orders
+func TestORSet_Merge_AddWins(t *testing.T) {
+	base := NewORSet[int]()
+	base.Add("n0", 1)
+
+	a := base.Copy()
+	a.Remove("n0", 1)
+
+	b := base.Copy()
+	b.Add("n1", 1)
+
+	ab := a.Copy()
+	ab.Merge(b)
+
+	ba := b.Copy()
+	ba.Merge(a)
+
+	if !ab.Contains(1) || !ba.Contains(1) {
+		t.Errorf("add-wins violated: merge(a,b)=%v, merge(b,a)=%v", ab.Elements(), ba.Elements())
+	}
+}
+
+// Commutativity: merge(a,b) and merge(b,a) contain the same elements
+func TestORSet_Merge_Commutative(t *testing.T) {
+	a := NewORSet[int]()
+	applyORSetOps(a, []orSetOp{{"n0", false, 1}, {"n0", false, 2}, {"n0", true, 1}})
+	b := NewORSet[int]()
+	applyORSetOps(b, []orSetOp{{"n1", false, 2}, {"n1", false, 3}, {"n1", true, 2}})
+
+	ab := a.Copy()
+	ab.Merge(b)
+
+	ba := b.Copy()
+	ba.Merge(a)
+
+	if gotAB, gotBA := sortedElements(ab), sortedElements(ba); !slices.Equal(gotAB, gotBA) {
+		t.Errorf("commutativity violated: merge(a,b)=%v, merge(b,a)=%v", gotAB, gotBA)
+	}
+}
+
+// Associativity: merge(merge(a,b),c) and merge(a,merge(b,c)) contain the same elements
+func TestORSet_Merge_Associative(t *testing.T) {
+	a := NewORSet[int]()
+	applyORSetOps(a, []orSetOp{{"n0", false, 1}, {"n0", false, 2}})
+	b := a.Copy()
+	applyORSetOps(b, []orSetOp{{"n1", true, 1}, {"n1", false, 3}})
+	c := NewORSet[int]()
+	applyORSetOps(c, []orSetOp{{"n2", false, 1}, {"n2", false, 4}, {"n2", true, 4}})
+
+	left := a.Copy()
+	left.Merge(b)
+	left.Merge(c)
+
+	bc := b.Copy()
+	bc.Merge(c)
+	right := a.Copy()
+	right.Merge(bc)
+
+	if gotL, gotR := sortedElements(left), sortedElements(right); !slices.Equal(gotL, gotR) {
+		t.Errorf("associativity violated: merge(merge(a,b),c)=%v, merge(a,merge(b,c))=%v", gotL, gotR)
+	}
+}
+
+// Idempotence: merging a state into itself changes nothing
+func TestORSet_Merge_Idempotent(t *testing.T) {
+	a := NewORSet[int]()
+	applyORSetOps(a, []orSetOp{{"n0", false, 1}, {"n0", false, 2}, {"n0", true, 1}})
+	want := sortedElements(a)
+
+	a.Merge(a.Copy())
+	if got := sortedElements(a); !slices.Equal(got, want) {
+		t.Errorf("idempotence violated: got %v, want %v", got, want)
+	}
+}
+
+func TestORSet_Copy(t *testing.T) {
+	c := NewORSet[int]()
+	c.Add("n0", 1)
+	cpy := c.Copy()
+	c.Remove("n0", 1)
+	cpy.Add("n1", 2)
+
+	if got := sortedElements(cpy); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("Copy() was affected by mutation of original: got %v", got)
+	}
+	if got := sortedElements(c); !slices.Equal(got, []int{}) {
+		t.Errorf("Original was affected by mutation of copy: got %v", got)
+	}
+}
+
+func TestORSet_JSON(t *testing.T) {
+	c := NewORSet[int]()
+	applyORSetOps(c, []orSetOp{{"n0", false, 1}, {"n1", false, 2}, {"n1", true, 1}})
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got := NewORSet[int]()
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if gotEl, wantEl := sortedElements(got), sortedElements(c); !slices.Equal(gotEl, wantEl) {
+		t.Errorf("round trip Elements() = %v, want %v", gotEl, wantEl)
+	}
+
+	// Tombstones must survive the round trip, otherwise the old add resurrects
+	stale := NewORSet[int]()
+	stale.Add("n0", 1)
+	got.Merge(stale)
+	if got.Contains(1) {
+		t.Errorf("tombstone lost in round trip: Elements() = %v", got.Elements())
+	}
+}
@@ -1,42 +1,78 @@
 package crdt
 
 import (
+	"encoding/json"
 	"maps"
 )
 
-type PNCounter struct {
-	Positive GCounter
-	Negative GCounter
+// Signed constrains PNCounter values, which must be able to express
+// decrements.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
 }
 
-func NewPNCounter() PNCounter {
-	return PNCounter{
-		Positive: make(GCounter),
-		Negative: make(GCounter),
+type PNCounter[K comparable, V Signed] struct {
+	Positive GCounter[K, V]
+	Negative GCounter[K, V]
+}
+
+func NewPNCounter[K comparable, V Signed]() PNCounter[K, V] {
+	return PNCounter[K, V]{
+		Positive: make(GCounter[K, V]),
+		Negative: make(GCounter[K, V]),
 	}
 }
 
-func (c PNCounter) Increment(key string, delta int) {
+// Increment applies delta to the entry for key and returns the delta-state
+// holding just the touched entry.
+func (c PNCounter[K, V]) Increment(key K, delta V) PNCounter[K, V] {
+	d := NewPNCounter[K, V]()
 	if delta >= 0 {
-		c.Positive.Increment(key, delta)
+		d.Positive = c.Positive.Increment(key, delta)
 	}
 	if delta < 0 {
-		c.Negative.Increment(key, -delta)
+		d.Negative = c.Negative.Increment(key, -delta)
 	}
+	return d
 }
 
-func (c PNCounter) Value() int {
+func (c PNCounter[K, V]) Value() V {
 	return c.Positive.Value() - c.Negative.Value()
 }
 
-func (c PNCounter) Merge(other PNCounter) {
+func (c PNCounter[K, V]) Merge(other PNCounter[K, V]) {
 	c.Positive.Merge(other.Positive)
 	c.Negative.Merge(other.Negative)
 }
 
-func (c PNCounter) Copy() PNCounter {
-	cpy := NewPNCounter()
+// Compact drops zero entries, see GCounter.Compact.
+func (c PNCounter[K, V]) Compact() {
+	c.Positive.Compact()
+	c.Negative.Compact()
+}
+
+func (c PNCounter[K, V]) Copy() PNCounter[K, V] {
+	cpy := NewPNCounter[K, V]()
 	maps.Copy(cpy.Positive, c.Positive)
 	maps.Copy(cpy.Negative, c.Negative)
 	return cpy
 }
+
+func (c PNCounter[K, V]) Equal(other PNCounter[K, V]) bool {
+	return c.Positive.Equal(other.Positive) && c.Negative.Equal(other.Negative)
+}
+
+func (c PNCounter[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pnCounterJSON[K, V](c))
+}
+
+func (c *PNCounter[K, V]) UnmarshalJSON(data []byte) error {
+	fields := pnCounterJSON[K, V](NewPNCounter[K, V]())
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*c = PNCounter[K, V](fields)
+	return nil
+}
+
+type pnCounterJSON[K comparable, V Signed] PNCounter[K, V]
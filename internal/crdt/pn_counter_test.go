@@ -1,6 +1,10 @@
 package crdt
 
-import "testing"
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+)
 
 func TestPNCounter_Increment(t *testing.T) {
 	tests := []struct {
@@ -72,7 +76,7 @@ func TestPNCounter_Increment(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := NewPNCounter()
+			c := NewPNCounter[string, int]()
 			for _, op := range tt.ops {
 				c.Increment(op.key, op.delta)
 			}
@@ -83,30 +87,63 @@ func TestPNCounter_Increment(t *testing.T) {
 	}
 }
 
+func TestPNCounter_Increment_Delta(t *testing.T) {
+	tests := []struct {
+		name  string
+		c     PNCounter[string, int]
+		key   string
+		delta int
+		want  PNCounter[string, int]
+	}{
+		{
+			name:  "increment carries positive entry only",
+			c:     PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 5, "n1": 2}, Negative: GCounter[string, int]{"n0": 1}},
+			key:   "n0",
+			delta: 3,
+			want:  PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 8}, Negative: GCounter[string, int]{}},
+		},
+		{
+			name:  "decrement carries negative entry only",
+			c:     PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 5}, Negative: GCounter[string, int]{"n0": 1, "n1": 4}},
+			key:   "n0",
+			delta: -2,
+			want:  PNCounter[string, int]{Positive: GCounter[string, int]{}, Negative: GCounter[string, int]{"n0": 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.c.Increment(tt.key, tt.delta)
+			if !maps.Equal(got.Positive, tt.want.Positive) || !maps.Equal(got.Negative, tt.want.Negative) {
+				t.Errorf("delta = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPNCounter_Value(t *testing.T) {
 	tests := []struct {
 		name string
-		c    PNCounter
+		c    PNCounter[string, int]
 		want int
 	}{
 		{
 			name: "empty counter",
-			c:    NewPNCounter(),
+			c:    NewPNCounter[string, int](),
 			want: 0,
 		},
 		{
 			name: "only positive",
-			c:    PNCounter{Positive: GCounter{"n0": 10}, Negative: GCounter{}},
+			c:    PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 10}, Negative: GCounter[string, int]{}},
 			want: 10,
 		},
 		{
 			name: "only negative",
-			c:    PNCounter{Positive: GCounter{}, Negative: GCounter{"n0": 4}},
+			c:    PNCounter[string, int]{Positive: GCounter[string, int]{}, Negative: GCounter[string, int]{"n0": 4}},
 			want: -4,
 		},
 		{
 			name: "positive and negative",
-			c:    PNCounter{Positive: GCounter{"n0": 10}, Negative: GCounter{"n0": 4}},
+			c:    PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 10}, Negative: GCounter[string, int]{"n0": 4}},
 			want: 6,
 		},
 	}
@@ -122,44 +159,44 @@ func TestPNCounter_Value(t *testing.T) {
 func TestPNCounter_Merge(t *testing.T) {
 	tests := []struct {
 		name      string
-		c         PNCounter
-		other     PNCounter
+		c         PNCounter[string, int]
+		other     PNCounter[string, int]
 		wantValue int
 	}{
 		{
 			name:      "merge disjoint increments",
-			c:         PNCounter{Positive: GCounter{"n0": 5}, Negative: GCounter{}},
-			other:     PNCounter{Positive: GCounter{"n1": 3}, Negative: GCounter{}},
+			c:         PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 5}, Negative: GCounter[string, int]{}},
+			other:     PNCounter[string, int]{Positive: GCounter[string, int]{"n1": 3}, Negative: GCounter[string, int]{}},
 			wantValue: 8,
 		},
 		{
 			name:      "merge disjoint decrements",
-			c:         PNCounter{Positive: GCounter{}, Negative: GCounter{"n0": 2}},
-			other:     PNCounter{Positive: GCounter{}, Negative: GCounter{"n1": 3}},
+			c:         PNCounter[string, int]{Positive: GCounter[string, int]{}, Negative: GCounter[string, int]{"n0": 2}},
+			other:     PNCounter[string, int]{Positive: GCounter[string, int]{}, Negative: GCounter[string, int]{"n1": 3}},
 			wantValue: -5,
 		},
 		{
 			name:      "max wins on positive",
-			c:         PNCounter{Positive: GCounter{"n0": 10}, Negative: GCounter{}},
-			other:     PNCounter{Positive: GCounter{"n0": 6}, Negative: GCounter{}},
+			c:         PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 10}, Negative: GCounter[string, int]{}},
+			other:     PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 6}, Negative: GCounter[string, int]{}},
 			wantValue: 10,
 		},
 		{
 			name:      "max wins on negative",
-			c:         PNCounter{Positive: GCounter{}, Negative: GCounter{"n0": 3}},
-			other:     PNCounter{Positive: GCounter{}, Negative: GCounter{"n0": 7}},
+			c:         PNCounter[string, int]{Positive: GCounter[string, int]{}, Negative: GCounter[string, int]{"n0": 3}},
+			other:     PNCounter[string, int]{Positive: GCounter[string, int]{}, Negative: GCounter[string, int]{"n0": 7}},
 			wantValue: -7,
 		},
 		{
 			name:      "idempotent — merge with equal state",
-			c:         PNCounter{Positive: GCounter{"n0": 5}, Negative: GCounter{"n0": 2}},
-			other:     PNCounter{Positive: GCounter{"n0": 5}, Negative: GCounter{"n0": 2}},
+			c:         PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 5}, Negative: GCounter[string, int]{"n0": 2}},
+			other:     PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 5}, Negative: GCounter[string, int]{"n0": 2}},
 			wantValue: 3,
 		},
 		{
 			name:      "merge with empty",
-			c:         PNCounter{Positive: GCounter{"n0": 5}, Negative: GCounter{"n0": 2}},
-			other:     NewPNCounter(),
+			c:         PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 5}, Negative: GCounter[string, int]{"n0": 2}},
+			other:     NewPNCounter[string, int](),
 			wantValue: 3,
 		},
 	}
@@ -175,8 +212,8 @@ func TestPNCounter_Merge(t *testing.T) {
 
 // Commutativity: merge(a,b) and merge(b,a) produce the same value
 func TestPNCounter_Merge_Commutative(t *testing.T) {
-	a := PNCounter{Positive: GCounter{"n0": 5, "n1": 2}, Negative: GCounter{"n0": 1}}
-	b := PNCounter{Positive: GCounter{"n0": 3, "n1": 7}, Negative: GCounter{"n1": 4}}
+	a := PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 5, "n1": 2}, Negative: GCounter[string, int]{"n0": 1}}
+	b := PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 3, "n1": 7}, Negative: GCounter[string, int]{"n1": 4}}
 
 	ab := a.Copy()
 	ab.Merge(b)
@@ -191,7 +228,7 @@ func TestPNCounter_Merge_Commutative(t *testing.T) {
 
 func TestPNCounter_Copy(t *testing.T) {
 	t.Run("copy has same value", func(t *testing.T) {
-		c := NewPNCounter()
+		c := NewPNCounter[string, int]()
 		c.Increment("n0", 5)
 		c.Increment("n1", -3)
 		cpy := c.Copy()
@@ -201,7 +238,7 @@ func TestPNCounter_Copy(t *testing.T) {
 	})
 
 	t.Run("copy is independent — mutating original does not affect copy", func(t *testing.T) {
-		c := NewPNCounter()
+		c := NewPNCounter[string, int]()
 		c.Increment("n0", 5)
 		cpy := c.Copy()
 		c.Increment("n0", 10)
@@ -211,7 +248,7 @@ func TestPNCounter_Copy(t *testing.T) {
 	})
 
 	t.Run("copy is independent — mutating copy does not affect original", func(t *testing.T) {
-		c := NewPNCounter()
+		c := NewPNCounter[string, int]()
 		c.Increment("n0", 5)
 		cpy := c.Copy()
 		cpy.Increment("n0", 10)
@@ -223,10 +260,10 @@ func TestPNCounter_Copy(t *testing.T) {
 
 // Monotonicity: merging can only move value in the direction of more information
 func TestPNCounter_Merge_Monotone(t *testing.T) {
-	a := NewPNCounter()
+	a := NewPNCounter[string, int]()
 	a.Increment("n0", 10)
 
-	b := NewPNCounter()
+	b := NewPNCounter[string, int]()
 	b.Increment("n0", 10)
 	b.Increment("n0", -3)
 
@@ -238,3 +275,40 @@ func TestPNCounter_Merge_Monotone(t *testing.T) {
 		t.Errorf("positive side grew unexpectedly: before=%d, after=%d", before, after)
 	}
 }
+
+// Wire compatibility: the generic counter encodes exactly like the former struct of map[string]int
+func TestPNCounter_JSON_WireCompatible(t *testing.T) {
+	legacy := struct {
+		Positive map[string]int
+		Negative map[string]int
+	}{
+		Positive: map[string]int{"n0": 5},
+		Negative: map[string]int{"n1": 2},
+	}
+
+	got, err := json.Marshal(PNCounter[string, int]{Positive: GCounter[string, int]{"n0": 5}, Negative: GCounter[string, int]{"n1": 2}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want, _ := json.Marshal(legacy)
+	if string(got) != string(want) {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+
+	decoded := NewPNCounter[string, int]()
+	if err := json.Unmarshal(want, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got := decoded.Value(); got != 3 {
+		t.Errorf("Value() after Unmarshal = %d, want 3", got)
+	}
+}
+
+func TestPNCounter_OtherInstantiations(t *testing.T) {
+	c := NewPNCounter[int, int64]()
+	c.Increment(0, 1<<40)
+	c.Increment(1, -(1 << 39))
+	if got := c.Value(); got != 1<<39 {
+		t.Errorf("Value() = %d, want %d", got, int64(1<<39))
+	}
+}
@@ -0,0 +1,154 @@
+package crdt
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// RangeSet is a grow-only set of integers stored as sorted, disjoint and
+// non-adjacent closed intervals, so dense runs of IDs cost O(runs) both in
+// memory and on the wire, where it is encoded as [[lo,hi],...].
+type RangeSet[T Integer] struct {
+	ranges [][2]T
+}
+
+func NewRangeSet[T Integer](elements ...T) *RangeSet[T] {
+	s := &RangeSet[T]{}
+	for _, element := range elements {
+		s.Add(element)
+	}
+	return s
+}
+
+// Add inserts element and returns the delta-state holding just that element.
+func (s *RangeSet[T]) Add(element T) *RangeSet[T] {
+	delta := &RangeSet[T]{ranges: [][2]T{{element, element}}}
+
+	// i is the first interval starting after element
+	i := sort.Search(len(s.ranges), func(i int) bool { return s.ranges[i][0] > element })
+	if i > 0 && s.ranges[i-1][1] >= element {
+		return delta
+	}
+	joinPrev := i > 0 && s.ranges[i-1][1]+1 == element
+	joinNext := i < len(s.ranges) && s.ranges[i][0]-1 == element
+	switch {
+	case joinPrev && joinNext:
+		s.ranges[i-1][1] = s.ranges[i][1]
+		s.ranges = slices.Delete(s.ranges, i, i+1)
+	case joinPrev:
+		s.ranges[i-1][1] = element
+	case joinNext:
+		s.ranges[i][0] = element
+	default:
+		s.ranges = slices.Insert(s.ranges, i, [2]T{element, element})
+	}
+	return delta
+}
+
+func (s *RangeSet[T]) Contains(element T) bool {
+	i := sort.Search(len(s.ranges), func(i int) bool { return s.ranges[i][0] > element })
+	return i > 0 && s.ranges[i-1][1] >= element
+}
+
+// Len returns the number of elements in the set.
+func (s *RangeSet[T]) Len() int {
+	n := 0
+	for _, r := range s.ranges {
+		n += int(r[1]-r[0]) + 1
+	}
+	return n
+}
+
+func (s *RangeSet[T]) Elements() []T {
+	elements := make([]T, 0, s.Len())
+	for _, r := range s.ranges {
+		for element := r[0]; ; element++ {
+			elements = append(elements, element)
+			if element == r[1] {
+				break
+			}
+		}
+	}
+	return elements
+}
+
+// Ranges returns the closed intervals making up the set in ascending order.
+func (s *RangeSet[T]) Ranges() [][2]T {
+	return slices.Clone(s.ranges)
+}
+
+// Merge takes the union of both sets in a single pass over their intervals.
+func (s *RangeSet[T]) Merge(other *RangeSet[T]) {
+	s.ranges = unionRanges(s.ranges, other.ranges)
+}
+
+func (s *RangeSet[T]) Copy() *RangeSet[T] {
+	return &RangeSet[T]{ranges: slices.Clone(s.ranges)}
+}
+
+func (s *RangeSet[T]) MarshalJSON() ([]byte, error) {
+	if s.ranges == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s.ranges)
+}
+
+// UnmarshalJSON accepts intervals in any order, overlapping or adjacent, and
+// normalizes them.
+func (s *RangeSet[T]) UnmarshalJSON(data []byte) error {
+	var ranges [][2]T
+	if err := json.Unmarshal(data, &ranges); err != nil {
+		return err
+	}
+	return s.setRanges(ranges)
+}
+
+// setRanges replaces the set with the given intervals after checking and
+// normalizing them.
+func (s *RangeSet[T]) setRanges(ranges [][2]T) error {
+	for _, r := range ranges {
+		if r[0] > r[1] {
+			return fmt.Errorf("invalid interval [%d,%d]", r[0], r[1])
+		}
+	}
+	slices.SortFunc(ranges, func(a, b [2]T) int { return cmp.Compare(a[0], b[0]) })
+	s.ranges = unionRanges(nil, ranges)
+	return nil
+}
+
+// unionRanges merges two sorted interval lists, coalescing overlapping and
+// adjacent intervals. Neither input needs to be coalesced itself.
+func unionRanges[T Integer](a, b [][2]T) [][2]T {
+	out := make([][2]T, 0, len(a)+len(b))
+	push := func(r [2]T) {
+		if n := len(out); n > 0 && (r[0] <= out[n-1][1] || r[0]-1 == out[n-1][1]) {
+			out[n-1][1] = max(out[n-1][1], r[1])
+			return
+		}
+		out = append(out, r)
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i][0] <= b[j][0] {
+			push(a[i])
+			i++
+		} else {
+			push(b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		push(a[i])
+	}
+	for ; j < len(b); j++ {
+		push(b[j])
+	}
+	return out
+}
+
+func (s *RangeSet[T]) Equal(other *RangeSet[T]) bool {
+	return slices.Equal(s.ranges, other.ranges)
+}
@@ -0,0 +1,281 @@
+package crdt
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestRangeSet_Add(t *testing.T) {
+	tests := []struct {
+		name     string
+		elements []int
+		want     [][2]int
+	}{
+		{
+			name: "empty set",
+			want: nil,
+		},
+		{
+			name:     "single element",
+			elements: []int{5},
+			want:     [][2]int{{5, 5}},
+		},
+		{
+			name:     "duplicate — no growth",
+			elements: []int{5, 5},
+			want:     [][2]int{{5, 5}},
+		},
+		{
+			name:     "dense run collapses into one interval",
+			elements: []int{1, 2, 3, 4},
+			want:     [][2]int{{1, 4}},
+		},
+		{
+			name:     "descending run collapses into one interval",
+			elements: []int{4, 3, 2, 1},
+			want:     [][2]int{{1, 4}},
+		},
+		{
+			name:     "gap keeps intervals apart",
+			elements: []int{1, 2, 5, 6},
+			want:     [][2]int{{1, 2}, {5, 6}},
+		},
+		{
+			name:     "filling a gap joins neighbours",
+			elements: []int{1, 2, 4, 5, 3},
+			want:     [][2]int{{1, 5}},
+		},
+		{
+			name:     "element inside existing interval",
+			elements: []int{1, 2, 3, 2},
+			want:     [][2]int{{1, 3}},
+		},
+		{
+			name:     "negative elements",
+			elements: []int{-1, 0, 1, -5},
+			want:     [][2]int{{-5, -5}, {-1, 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewRangeSet(tt.elements...)
+			if got := s.Ranges(); !slices.Equal(got, tt.want) {
+				t.Errorf("Ranges() = %v, want %v", got, tt.want)
+			}
+			for _, element := range tt.elements {
+				if !s.Contains(element) {
+					t.Errorf("Contains(%d) = false, want true", element)
+				}
+			}
+		})
+	}
+}
+
+func TestRangeSet_Add_Delta(t *testing.T) {
+	s := NewRangeSet(1, 2)
+	delta := s.Add(3)
+	if got := delta.Ranges(); !slices.Equal(got, [][2]int{{3, 3}}) {
+		t.Errorf("delta Ranges() = %v, want [[3 3]]", got)
+	}
+}
+
+func TestRangeSet_Add_Bounds(t *testing.T) {
+	s := NewRangeSet[uint8](math.MaxUint8, 0, math.MaxUint8-1, 1)
+	if got, want := s.Ranges(), [][2]uint8{{0, 1}, {math.MaxUint8 - 1, math.MaxUint8}}; !slices.Equal(got, want) {
+		t.Errorf("Ranges() = %v, want %v", got, want)
+	}
+	if got := s.Len(); got != 4 {
+		t.Errorf("Len() = %d, want 4", got)
+	}
+}
+
+func TestRangeSet_Contains(t *testing.T) {
+	s := NewRangeSet(1, 2, 3, 7)
+	tests := []struct {
+		element int
+		want    bool
+	}{
+		{0, false},
+		{1, true},
+		{3, true},
+		{4, false},
+		{7, true},
+		{8, false},
+	}
+	for _, tt := range tests {
+		if got := s.Contains(tt.element); got != tt.want {
+			t.Errorf("Contains(%d) = %v, want %v", tt.element, got, tt.want)
+		}
+	}
+}
+
+func TestRangeSet_Elements(t *testing.T) {
+	s := NewRangeSet(5, 1, 2, 3, 9)
+	if got, want := s.Elements(), []int{1, 2, 3, 5, 9}; !slices.Equal(got, want) {
+		t.Errorf("Elements() = %v, want %v", got, want)
+	}
+	if got := s.Len(); got != 5 {
+		t.Errorf("Len() = %d, want 5", got)
+	}
+}
+
+func TestRangeSet_Merge(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     []int
+		other []int
+		want  [][2]int
+	}{
+		{
+			name:  "merge disjoint sets",
+			s:     []int{1, 2},
+			other: []int{5, 6},
+			want:  [][2]int{{1, 2}, {5, 6}},
+		},
+		{
+			name:  "merge adjacent intervals",
+			s:     []int{1, 2},
+			other: []int{3, 4},
+			want:  [][2]int{{1, 4}},
+		},
+		{
+			name:  "merge overlapping intervals",
+			s:     []int{1, 2, 3},
+			other: []int{2, 3, 4, 5},
+			want:  [][2]int{{1, 5}},
+		},
+		{
+			name:  "interval spanning several",
+			s:     []int{1, 3, 5, 9},
+			other: []int{2, 3, 4, 5, 6},
+			want:  [][2]int{{1, 6}, {9, 9}},
+		},
+		{
+			name:  "idempotent — merge with equal state",
+			s:     []int{1, 2, 4},
+			other: []int{1, 2, 4},
+			want:  [][2]int{{1, 2}, {4, 4}},
+		},
+		{
+			name:  "merge with empty",
+			s:     []int{1},
+			other: nil,
+			want:  [][2]int{{1, 1}},
+		},
+		{
+			name:  "merge into empty",
+			s:     nil,
+			other: []int{1, 2},
+			want:  [][2]int{{1, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewRangeSet(tt.s...)
+			s.Merge(NewRangeSet(tt.other...))
+			if got := s.Ranges(); !slices.Equal(got, tt.want) {
+				t.Errorf("Ranges() after Merge = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Commutativity: merge(a,b) and merge(b,a) hold the same intervals
+func TestRangeSet_Merge_Commutative(t *testing.T) {
+	a := NewRangeSet(1, 2, 3, 10, 12)
+	b := NewRangeSet(4, 11, 20)
+
+	ab := a.Copy()
+	ab.Merge(b)
+	ba := b.Copy()
+	ba.Merge(a)
+
+	if gotAB, gotBA := ab.Ranges(), ba.Ranges(); !slices.Equal(gotAB, gotBA) {
+		t.Errorf("commutativity violated: merge(a,b)=%v, merge(b,a)=%v", gotAB, gotBA)
+	}
+}
+
+func TestRangeSet_Copy(t *testing.T) {
+	s := NewRangeSet(1, 2)
+	cpy := s.Copy()
+	s.Add(3)
+	cpy.Add(0)
+
+	if got := cpy.Ranges(); !slices.Equal(got, [][2]int{{0, 2}}) {
+		t.Errorf("Copy() was affected by mutation of original: got %v", got)
+	}
+	if got := s.Ranges(); !slices.Equal(got, [][2]int{{1, 3}}) {
+		t.Errorf("Original was affected by mutation of copy: got %v", got)
+	}
+}
+
+func TestRangeSet_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		elements []int
+		want     string
+	}{
+		{name: "empty set", want: "[]"},
+		{name: "runs", elements: []int{1, 2, 3, 5, 7, 8}, want: "[[1,3],[5,5],[7,8]]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(NewRangeSet(tt.elements...))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangeSet_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    [][2]int
+		wantErr bool
+	}{
+		{name: "empty", data: "[]", want: [][2]int{}},
+		{name: "normalized input", data: "[[1,3],[5,5]]", want: [][2]int{{1, 3}, {5, 5}}},
+		{name: "unsorted overlapping input", data: "[[5,9],[1,3],[2,6]]", want: [][2]int{{1, 9}}},
+		{name: "adjacent input", data: "[[1,2],[3,4]]", want: [][2]int{{1, 4}}},
+		{name: "inverted interval", data: "[[3,1]]", wantErr: true},
+		{name: "not an interval list", data: `{"1":{}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got RangeSet[int]
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got.Ranges(), tt.want) {
+				t.Errorf("Ranges() = %v, want %v", got.Ranges(), tt.want)
+			}
+		})
+	}
+}
+
+// Dense message IDs take O(runs) bytes instead of O(n) as a GSet would
+func TestRangeSet_PayloadSize(t *testing.T) {
+	set := GSet[int]{}
+	ranges := NewRangeSet[int]()
+	for i := range 1000 {
+		if i%100 == 99 {
+			continue
+		}
+		set.Add(i)
+		ranges.Add(i)
+	}
+
+	setJSON, _ := json.Marshal(set)
+	rangesJSON, _ := json.Marshal(ranges)
+	if len(rangesJSON)*50 > len(setJSON) {
+		t.Errorf("RangeSet payload = %d bytes, GSet payload = %d bytes, want at least 50x smaller", len(rangesJSON), len(setJSON))
+	}
+}
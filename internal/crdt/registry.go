@@ -0,0 +1,229 @@
+package crdt
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Replica is a StateCRDT with its type erased, so a node can host whichever
+// CRDT was selected by name and still merge states received from peers.
+type Replica interface {
+	json.Marshaler
+	json.Unmarshaler
+	// Apply performs the client operation in body, issued at node.
+	Apply(node string, body json.RawMessage) error
+	// Read returns the value clients observe.
+	Read() any
+	Merge(other Replica) error
+	Copy() Replica
+	Equal(other Replica) bool
+}
+
+// Type describes how a registered StateCRDT is created, updated by client
+// operations and read.
+type Type[T StateCRDT[T]] struct {
+	New   func() T
+	Apply func(state T, node string, body json.RawMessage) error
+	Read  func(state T) any
+}
+
+type replica[T StateCRDT[T]] struct {
+	typ   *Type[T]
+	state T
+}
+
+func (r *replica[T]) Apply(node string, body json.RawMessage) error {
+	return r.typ.Apply(r.state, node, body)
+}
+
+func (r *replica[T]) Read() any {
+	return r.typ.Read(r.state)
+}
+
+func (r *replica[T]) Merge(other Replica) error {
+	o, ok := other.(*replica[T])
+	if !ok {
+		return fmt.Errorf("cannot merge %T into %T", other, r)
+	}
+	r.state.Merge(o.state)
+	return nil
+}
+
+func (r *replica[T]) Copy() Replica {
+	return &replica[T]{typ: r.typ, state: r.state.Copy()}
+}
+
+func (r *replica[T]) Equal(other Replica) bool {
+	o, ok := other.(*replica[T])
+	return ok && r.state.Equal(o.state)
+}
+
+func (r *replica[T]) MarshalJSON() ([]byte, error) {
+	return r.state.MarshalJSON()
+}
+
+func (r *replica[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &r.state)
+}
+
+var registry = map[string]func() Replica{}
+
+// Register makes a StateCRDT available to New under name.
+func Register[T StateCRDT[T]](name string, typ Type[T]) {
+	if _, ok := registry[name]; ok {
+		panic("crdt type already registered: " + name)
+	}
+	registry[name] = func() Replica {
+		return &replica[T]{typ: &typ, state: typ.New()}
+	}
+}
+
+// New returns an empty replica of the StateCRDT registered under name.
+func New(name string) (Replica, error) {
+	newReplica, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown crdt type %q", name)
+	}
+	return newReplica(), nil
+}
+
+// Names returns the names of all registered types in sorted order.
+func Names() []string {
+	return slices.Sorted(maps.Keys(registry))
+}
+
+type counterOp struct {
+	Delta int `json:"delta"`
+}
+
+type boundedCounterOp struct {
+	Type   string `json:"type"`
+	Delta  int    `json:"delta"`
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+type keyedCounterOp struct {
+	Type  string `json:"type"`
+	Key   string `json:"key"`
+	Delta int    `json:"delta"`
+}
+
+type elementOp struct {
+	Type    string `json:"type"`
+	Element int    `json:"element"`
+}
+
+func init() {
+	Register("g-counter", Type[GCounter[string, int]]{
+		New: func() GCounter[string, int] { return make(GCounter[string, int]) },
+		Apply: func(c GCounter[string, int], node string, body json.RawMessage) error {
+			var op counterOp
+			if err := json.Unmarshal(body, &op); err != nil {
+				return err
+			}
+			if op.Delta < 0 {
+				return fmt.Errorf("g-counter cannot be decremented by %d", op.Delta)
+			}
+			c.Increment(node, op.Delta)
+			return nil
+		},
+		Read: func(c GCounter[string, int]) any { return c.Value() },
+	})
+	Register("pn-counter", Type[PNCounter[string, int]]{
+		New: NewPNCounter[string, int],
+		Apply: func(c PNCounter[string, int], node string, body json.RawMessage) error {
+			var op counterOp
+			if err := json.Unmarshal(body, &op); err != nil {
+				return err
+			}
+			c.Increment(node, op.Delta)
+			return nil
+		},
+		Read: func(c PNCounter[string, int]) any { return c.Value() },
+	})
+	Register("bounded-counter", Type[BoundedCounter]{
+		New: NewBoundedCounter,
+		Apply: func(c BoundedCounter, node string, body json.RawMessage) error {
+			var op boundedCounterOp
+			if err := json.Unmarshal(body, &op); err != nil {
+				return err
+			}
+			var err error
+			switch {
+			case op.Type == "transfer":
+				_, err = c.Transfer(node, op.To, op.Amount)
+			case op.Delta < 0:
+				_, err = c.Decrement(node, -op.Delta)
+			default:
+				_, err = c.Increment(node, op.Delta)
+			}
+			return err
+		},
+		Read: func(c BoundedCounter) any { return c.Value() },
+	})
+	Register("pn-counter-map", Type[*ORMap[string, PNCounter[string, int]]]{
+		New: func() *ORMap[string, PNCounter[string, int]] {
+			m := NewORMap[string](NewPNCounter[string, int])
+			return &m
+		},
+		Apply: func(m *ORMap[string, PNCounter[string, int]], node string, body json.RawMessage) error {
+			var op keyedCounterOp
+			if err := json.Unmarshal(body, &op); err != nil {
+				return err
+			}
+			if op.Type == "remove" {
+				m.Remove(node, op.Key)
+				return nil
+			}
+			m.Update(node, op.Key, func(c PNCounter[string, int]) { c.Increment(node, op.Delta) })
+			return nil
+		},
+		Read: func(m *ORMap[string, PNCounter[string, int]]) any {
+			values := make(map[string]int)
+			for _, key := range m.Keys() {
+				c, _ := m.Get(key)
+				values[key] = c.Value()
+			}
+			return values
+		},
+	})
+	Register("g-set", Type[GSet[int]]{
+		New: func() GSet[int] { return make(GSet[int]) },
+		Apply: func(c GSet[int], _ string, body json.RawMessage) error {
+			var op elementOp
+			if err := json.Unmarshal(body, &op); err != nil {
+				return err
+			}
+			c.Add(op.Element)
+			return nil
+		},
+		Read: func(c GSet[int]) any {
+			elements := c.Elements()
+			slices.Sort(elements)
+			return elements
+		},
+	})
+	Register("or-set", Type[ORSet[int]]{
+		New: NewORSet[int],
+		Apply: func(c ORSet[int], node string, body json.RawMessage) error {
+			var op elementOp
+			if err := json.Unmarshal(body, &op); err != nil {
+				return err
+			}
+			if op.Type == "remove" {
+				c.Remove(node, op.Element)
+			} else {
+				c.Add(node, op.Element)
+			}
+			return nil
+		},
+		Read: func(c ORSet[int]) any {
+			elements := c.Elements()
+			slices.Sort(elements)
+			return elements
+		},
+	})
+}
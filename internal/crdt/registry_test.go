@@ -0,0 +1,185 @@
+package crdt
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	names := Names()
+	for _, want := range []string{"bounded-counter", "g-counter", "g-set", "or-set", "pn-counter", "pn-counter-map"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("Names() = %v, missing %q", names, want)
+		}
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("Names() = %v, want sorted", names)
+	}
+}
+
+func TestNew_Unknown(t *testing.T) {
+	if _, err := New("no-such-crdt"); err == nil {
+		t.Errorf("New() error = nil, want error")
+	}
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register() with taken name did not panic")
+		}
+	}()
+	Register("g-set", Type[GSet[int]]{})
+}
+
+func TestReplica_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		crdt    string
+		ops     []string
+		want    any
+		wantErr bool
+	}{
+		{
+			name: "g-counter",
+			crdt: "g-counter",
+			ops:  []string{`{"type":"add","delta":3}`, `{"type":"add","delta":4}`},
+			want: 7,
+		},
+		{
+			name:    "g-counter rejects decrement",
+			crdt:    "g-counter",
+			ops:     []string{`{"type":"add","delta":-1}`},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "pn-counter",
+			crdt: "pn-counter",
+			ops:  []string{`{"type":"add","delta":3}`, `{"type":"add","delta":-5}`},
+			want: -2,
+		},
+		{
+			name: "bounded-counter",
+			crdt: "bounded-counter",
+			ops:  []string{`{"type":"add","delta":3}`, `{"type":"transfer","to":"n1","amount":1}`, `{"type":"add","delta":-2}`},
+			want: 1,
+		},
+		{
+			name:    "bounded-counter rejects decrement beyond rights",
+			crdt:    "bounded-counter",
+			ops:     []string{`{"type":"add","delta":3}`, `{"type":"add","delta":-4}`},
+			want:    3,
+			wantErr: true,
+		},
+		{
+			name: "g-set",
+			crdt: "g-set",
+			ops:  []string{`{"type":"add","element":2}`, `{"type":"add","element":1}`},
+			want: []int{1, 2},
+		},
+		{
+			name: "or-set",
+			crdt: "or-set",
+			ops:  []string{`{"type":"add","element":2}`, `{"type":"add","element":1}`, `{"type":"remove","element":2}`},
+			want: []int{1},
+		},
+		{
+			name: "pn-counter-map",
+			crdt: "pn-counter-map",
+			ops: []string{
+				`{"type":"add","key":"a","delta":3}`,
+				`{"type":"add","key":"b","delta":1}`,
+				`{"type":"add","key":"a","delta":-1}`,
+				`{"type":"remove","key":"b"}`,
+			},
+			want: map[string]int{"a": 2},
+		},
+		{
+			name:    "malformed op",
+			crdt:    "g-set",
+			ops:     []string{`{"type":"add","element":"x"}`},
+			want:    []int{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := New(tt.crdt)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			var gotErr error
+			for _, op := range tt.ops {
+				if err := r.Apply("n0", json.RawMessage(op)); err != nil {
+					gotErr = err
+				}
+			}
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("Apply() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if got := r.Read(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// A replica shipped as JSON and decoded into a fresh replica of the same type
+// merges like the original state
+func TestReplica_GossipRoundTrip(t *testing.T) {
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			local, _ := New(name)
+			remote, _ := New(name)
+			if err := remote.Apply("n1", json.RawMessage(`{"type":"add","delta":2,"element":2}`)); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+
+			data, err := json.Marshal(remote)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			received, _ := New(name)
+			if err := json.Unmarshal(data, received); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !received.Equal(remote) {
+				t.Errorf("decoded replica %s not Equal to original", data)
+			}
+
+			if err := local.Merge(received); err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+			if !reflect.DeepEqual(local.Read(), remote.Read()) {
+				t.Errorf("Read() after Merge = %v, want %v", local.Read(), remote.Read())
+			}
+		})
+	}
+}
+
+func TestReplica_MergeMismatch(t *testing.T) {
+	counter, _ := New("g-counter")
+	set, _ := New("g-set")
+	if err := counter.Merge(set); err == nil {
+		t.Errorf("Merge() of different types error = nil, want error")
+	}
+	if counter.Equal(set) {
+		t.Errorf("Equal() of different types = true, want false")
+	}
+}
+
+func TestReplica_Copy(t *testing.T) {
+	r, _ := New("g-counter")
+	cpy := r.Copy()
+	_ = r.Apply("n0", json.RawMessage(`{"delta":5}`))
+
+	if got := cpy.Read(); got != 0 {
+		t.Errorf("Copy() was affected by mutation of original: got %v, want 0", got)
+	}
+	if r.Equal(cpy) {
+		t.Errorf("Equal() = true after diverging, want false")
+	}
+}
@@ -0,0 +1,197 @@
+package crdt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+var (
+	ErrUnknownElement    = errors.New("unknown element")
+	ErrMissingDependency = errors.New("missing dependency")
+)
+
+// RGAElement is one inserted value and the element it was inserted after.
+// Deleted elements stay as tombstones so later inserts can still refer to them.
+type RGAElement[T any] struct {
+	After   Dot  `json:"after"`
+	Value   T    `json:"value"`
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+type RGAOpKind string
+
+const (
+	RGAInsert RGAOpKind = "insert"
+	RGADelete RGAOpKind = "delete"
+)
+
+// RGAOp is an operation as produced by InsertAfter and Delete, to be shipped
+// to other replicas and applied there with Apply.
+type RGAOp[T any] struct {
+	Kind  RGAOpKind `json:"kind"`
+	ID    Dot       `json:"id"`
+	After Dot       `json:"after,omitzero"`
+	Value T         `json:"value,omitzero"`
+}
+
+// RGA is a Replicated Growable Array: an ordered sequence in which every
+// element is identified by a Lamport timestamp dot and placed right after the
+// element it was inserted after. Concurrent inserts after the same element are
+// ordered newest first, so every replica sees the same sequence. The zero Dot
+// stands for the head of the sequence. Nodes holds every element ever
+// inserted, tombstones included, by its ID.
+type RGA[T any] struct {
+	Clock int                   `json:"clock"`
+	Nodes map[Dot]RGAElement[T] `json:"nodes"`
+}
+
+func NewRGA[T any]() *RGA[T] {
+	return &RGA[T]{Nodes: make(map[Dot]RGAElement[T])}
+}
+
+func (s *RGA[T]) known(id Dot) bool {
+	_, ok := s.Nodes[id]
+	return ok || id == Dot{}
+}
+
+// InsertAfter inserts value right after the element identified by after, or
+// at the head for the zero Dot, and returns the operation to replicate.
+func (s *RGA[T]) InsertAfter(node string, after Dot, value T) (RGAOp[T], error) {
+	if !s.known(after) {
+		return RGAOp[T]{}, fmt.Errorf("%w: %v", ErrUnknownElement, after)
+	}
+	s.Clock++
+	op := RGAOp[T]{Kind: RGAInsert, ID: Dot{Node: node, Seq: s.Clock}, After: after, Value: value}
+	s.Nodes[op.ID] = RGAElement[T]{After: after, Value: value}
+	return op, nil
+}
+
+// Delete removes the element identified by id and returns the operation to
+// replicate.
+func (s *RGA[T]) Delete(id Dot) (RGAOp[T], error) {
+	element, ok := s.Nodes[id]
+	if !ok {
+		return RGAOp[T]{}, fmt.Errorf("%w: %v", ErrUnknownElement, id)
+	}
+	element.Deleted = true
+	s.Nodes[id] = element
+	return RGAOp[T]{Kind: RGADelete, ID: id}, nil
+}
+
+// Apply performs an operation issued by another replica. Operations must be
+// delivered in causal order: an operation referring to an element this
+// replica has not seen yet fails with ErrMissingDependency and should be
+// retried later. Applying an operation twice has no further effect.
+func (s *RGA[T]) Apply(op RGAOp[T]) error {
+	switch op.Kind {
+	case RGAInsert:
+		if !s.known(op.After) {
+			return fmt.Errorf("%w: %v", ErrMissingDependency, op.After)
+		}
+		s.Clock = max(s.Clock, op.ID.Seq)
+		if _, ok := s.Nodes[op.ID]; !ok {
+			s.Nodes[op.ID] = RGAElement[T]{After: op.After, Value: op.Value}
+		}
+	case RGADelete:
+		element, ok := s.Nodes[op.ID]
+		if !ok {
+			return fmt.Errorf("%w: %v", ErrMissingDependency, op.ID)
+		}
+		element.Deleted = true
+		s.Nodes[op.ID] = element
+	default:
+		return fmt.Errorf("unknown rga op %q", op.Kind)
+	}
+	return nil
+}
+
+// order returns the IDs of all elements, tombstones included, in sequence order.
+func (s *RGA[T]) order() []Dot {
+	children := make(map[Dot][]Dot)
+	for id, element := range s.Nodes {
+		children[element.After] = append(children[element.After], id)
+	}
+	ids := make([]Dot, 0, len(s.Nodes))
+	// Depth-first walk from the head; the stack pops the newest child first
+	stack := []Dot{{}}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id != (Dot{}) {
+			ids = append(ids, id)
+		}
+		next := children[id]
+		slices.SortFunc(next, func(a, b Dot) int {
+			if a.Less(b) {
+				return -1
+			}
+			if b.Less(a) {
+				return 1
+			}
+			return 0
+		})
+		stack = append(stack, next...)
+	}
+	return ids
+}
+
+// IDs returns the identifiers of the visible elements in sequence order.
+func (s *RGA[T]) IDs() []Dot {
+	ids := s.order()
+	return slices.DeleteFunc(ids, func(id Dot) bool { return s.Nodes[id].Deleted })
+}
+
+// Elements returns the visible values in sequence order.
+func (s *RGA[T]) Elements() []T {
+	ids := s.IDs()
+	values := make([]T, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, s.Nodes[id].Value)
+	}
+	return values
+}
+
+func (s *RGA[T]) Merge(other *RGA[T]) {
+	s.Clock = max(s.Clock, other.Clock)
+	for id, remote := range other.Nodes {
+		element, ok := s.Nodes[id]
+		if !ok {
+			s.Nodes[id] = remote
+			continue
+		}
+		if remote.Deleted && !element.Deleted {
+			element.Deleted = true
+			s.Nodes[id] = element
+		}
+	}
+}
+
+func (s *RGA[T]) Copy() *RGA[T] {
+	return &RGA[T]{Clock: s.Clock, Nodes: maps.Clone(s.Nodes)}
+}
+
+// Equal compares element IDs and tombstones: every insert carries a unique ID.
+func (s *RGA[T]) Equal(other *RGA[T]) bool {
+	return s.Clock == other.Clock && maps.EqualFunc(s.Nodes, other.Nodes, func(a, b RGAElement[T]) bool {
+		return a.After == b.After && a.Deleted == b.Deleted
+	})
+}
+
+func (s *RGA[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rgaJSON[T](*s))
+}
+
+func (s *RGA[T]) UnmarshalJSON(data []byte) error {
+	fields := rgaJSON[T](*NewRGA[T]())
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*s = RGA[T](fields)
+	return nil
+}
+
+// rgaJSON has the fields of RGA but none of its methods.
+type rgaJSON[T any] RGA[T]
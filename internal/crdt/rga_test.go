@@ -0,0 +1,188 @@
+package crdt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestRGA_InsertDelete(t *testing.T) {
+	s := NewRGA[string]()
+	a, _ := s.InsertAfter("n0", Dot{}, "a")
+	c, _ := s.InsertAfter("n0", a.ID, "c")
+	if _, err := s.InsertAfter("n0", a.ID, "b"); err != nil {
+		t.Fatalf("InsertAfter() error = %v", err)
+	}
+	if got, want := s.Elements(), []string{"a", "b", "c"}; !slices.Equal(got, want) {
+		t.Errorf("Elements() = %v, want %v", got, want)
+	}
+
+	if _, err := s.Delete(c.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got, want := s.Elements(), []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("Elements() after Delete = %v, want %v", got, want)
+	}
+
+	// A tombstone can still be used as an anchor
+	if _, err := s.InsertAfter("n0", c.ID, "d"); err != nil {
+		t.Fatalf("InsertAfter(tombstone) error = %v", err)
+	}
+	if got, want := s.Elements(), []string{"a", "b", "d"}; !slices.Equal(got, want) {
+		t.Errorf("Elements() = %v, want %v", got, want)
+	}
+}
+
+func TestRGA_UnknownElement(t *testing.T) {
+	s := NewRGA[string]()
+	if _, err := s.InsertAfter("n0", Dot{"n1", 1}, "a"); !errors.Is(err, ErrUnknownElement) {
+		t.Errorf("InsertAfter() error = %v, want %v", err, ErrUnknownElement)
+	}
+	if _, err := s.Delete(Dot{"n1", 1}); !errors.Is(err, ErrUnknownElement) {
+		t.Errorf("Delete() error = %v, want %v", err, ErrUnknownElement)
+	}
+}
+
+// Concurrent inserts at the same position are ordered the same way on every
+// replica, newest first, and do not interleave with each other's successors
+func TestRGA_ConcurrentInsert(t *testing.T) {
+	base := NewRGA[string]()
+	head, _ := base.InsertAfter("n0", Dot{}, ">")
+
+	a := base.Copy()
+	x, _ := a.InsertAfter("n1", head.ID, "x")
+	a.InsertAfter("n1", x.ID, "y")
+
+	b := base.Copy()
+	b.InsertAfter("n2", head.ID, "1")
+	b.InsertAfter("n2", head.ID, "2")
+
+	ab := a.Copy()
+	ab.Merge(b)
+	ba := b.Copy()
+	ba.Merge(a)
+
+	want := []string{">", "2", "1", "x", "y"}
+	if got := ab.Elements(); !slices.Equal(got, want) {
+		t.Errorf("merge(a,b).Elements() = %v, want %v", got, want)
+	}
+	if got := ba.Elements(); !slices.Equal(got, want) {
+		t.Errorf("merge(b,a).Elements() = %v, want %v", got, want)
+	}
+}
+
+func TestRGA_Apply(t *testing.T) {
+	src := NewRGA[string]()
+	a, _ := src.InsertAfter("n0", Dot{}, "a")
+	b, _ := src.InsertAfter("n0", a.ID, "b")
+	del, _ := src.Delete(a.ID)
+
+	dst := NewRGA[string]()
+	if err := dst.Apply(b); !errors.Is(err, ErrMissingDependency) {
+		t.Errorf("Apply(insert before its anchor) error = %v, want %v", err, ErrMissingDependency)
+	}
+	if err := dst.Apply(del); !errors.Is(err, ErrMissingDependency) {
+		t.Errorf("Apply(delete before insert) error = %v, want %v", err, ErrMissingDependency)
+	}
+	for _, op := range []RGAOp[string]{a, b, del, a, del} {
+		if err := dst.Apply(op); err != nil {
+			t.Fatalf("Apply(%v) error = %v", op, err)
+		}
+	}
+	if !dst.Equal(src) {
+		t.Errorf("Apply() = %v, want %v", dst.Elements(), src.Elements())
+	}
+	if err := dst.Apply(RGAOp[string]{Kind: "move"}); err == nil {
+		t.Errorf("Apply(unknown kind) error = nil")
+	}
+}
+
+// Replicas editing concurrently and exchanging operations in random order,
+// with missing dependencies retried later, all end up with the same sequence
+func TestRGA_Apply_Converges(t *testing.T) {
+	for seed := range uint64(50) {
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			r := rand.New(rand.NewPCG(seed, 0))
+			nodes := []string{"n0", "n1", "n2"}
+			replicas := make([]*RGA[int], len(nodes))
+			for i := range replicas {
+				replicas[i] = NewRGA[int]()
+			}
+			inboxes := make([][]RGAOp[int], len(nodes))
+
+			for step := range 60 {
+				i := r.IntN(len(nodes))
+				s := replicas[i]
+				ids := s.IDs()
+				var op RGAOp[int]
+				if len(ids) > 0 && r.IntN(3) == 0 {
+					op, _ = s.Delete(ids[r.IntN(len(ids))])
+				} else {
+					after := Dot{}
+					if len(ids) > 0 {
+						after = ids[r.IntN(len(ids))]
+					}
+					op, _ = s.InsertAfter(nodes[i], after, step)
+				}
+				for j := range inboxes {
+					if j != i {
+						inboxes[j] = append(inboxes[j], op)
+					}
+				}
+				// Deliver a random subset of the pending operations, out of order
+				for j := range inboxes {
+					r.Shuffle(len(inboxes[j]), func(a, b int) {
+						inboxes[j][a], inboxes[j][b] = inboxes[j][b], inboxes[j][a]
+					})
+					inboxes[j] = deliverRGAOps(replicas[j], inboxes[j][:r.IntN(len(inboxes[j])+1)], inboxes[j])
+				}
+			}
+			for j := range inboxes {
+				for len(inboxes[j]) > 0 {
+					inboxes[j] = deliverRGAOps(replicas[j], inboxes[j], inboxes[j])
+				}
+			}
+
+			want := replicas[0].Elements()
+			for i, s := range replicas[1:] {
+				if got := s.Elements(); !slices.Equal(got, want) {
+					t.Errorf("replica %d Elements() = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+// deliverRGAOps applies the given operations and returns the pending ones that
+// are still undelivered.
+func deliverRGAOps(s *RGA[int], deliver, pending []RGAOp[int]) []RGAOp[int] {
+	delivered := map[RGAOp[int]]bool{}
+	for _, op := range deliver {
+		if err := s.Apply(op); err == nil {
+			delivered[op] = true
+		}
+	}
+	return slices.DeleteFunc(slices.Clone(pending), func(op RGAOp[int]) bool { return delivered[op] })
+}
+
+func TestRGA_JSON(t *testing.T) {
+	s := NewRGA[string]()
+	a, _ := s.InsertAfter("n0", Dot{}, "a")
+	s.InsertAfter("n1", a.ID, "b")
+	s.Delete(a.ID)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got := NewRGA[string]()
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !got.Equal(s) || !slices.Equal(got.Elements(), s.Elements()) {
+		t.Errorf("round trip = %v, want %v", got.Elements(), s.Elements())
+	}
+}
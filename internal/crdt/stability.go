@@ -0,0 +1,63 @@
+package crdt
+
+// StabilityTracker learns which events every replica has seen. Replicas
+// report their version vectors, e.g. the clock of a state they sent or
+// acknowledged; an event is causally stable once every tracked replica's
+// vector contains it, so no replica can still send state that predates it.
+type StabilityTracker struct {
+	seen map[string]VersionVector
+}
+
+// NewStabilityTracker tracks the given replicas, which should include the
+// local one.
+func NewStabilityTracker(nodes []string) *StabilityTracker {
+	seen := make(map[string]VersionVector, len(nodes))
+	for _, node := range nodes {
+		seen[node] = make(VersionVector)
+	}
+	return &StabilityTracker{seen: seen}
+}
+
+// Track starts waiting for node, e.g. once the cluster membership is known.
+// Nodes tracked already keep what they were observed to have seen.
+func (t *StabilityTracker) Track(node string) {
+	if _, ok := t.seen[node]; !ok {
+		t.seen[node] = make(VersionVector)
+	}
+}
+
+// Observe records that node has seen every event in vv. Untracked nodes are
+// ignored.
+func (t *StabilityTracker) Observe(node string, vv VersionVector) {
+	if seen, ok := t.seen[node]; ok {
+		seen.Merge(vv)
+	}
+}
+
+// Forget stops waiting for node, e.g. after it left the cluster for good.
+func (t *StabilityTracker) Forget(node string) {
+	delete(t.seen, node)
+}
+
+// Stable returns the pointwise minimum of all observed vectors: the events
+// known by every tracked replica.
+func (t *StabilityTracker) Stable() VersionVector {
+	var stable VersionVector
+	for _, seen := range t.seen {
+		if stable == nil {
+			stable = seen.Copy()
+			continue
+		}
+		for node, seq := range stable {
+			if seq = min(seq, seen[node]); seq == 0 {
+				delete(stable, node)
+			} else {
+				stable[node] = seq
+			}
+		}
+	}
+	if stable == nil {
+		return make(VersionVector)
+	}
+	return stable
+}
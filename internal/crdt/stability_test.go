@@ -0,0 +1,180 @@
+package crdt
+
+import "testing"
+
+func TestStabilityTracker_Stable(t *testing.T) {
+	tests := []struct {
+		name    string
+		observe map[string]VersionVector
+		forget  []string
+		want    VersionVector
+	}{
+		{
+			name: "nothing observed",
+			want: VersionVector{},
+		},
+		{
+			name: "one replica lags",
+			observe: map[string]VersionVector{
+				"n0": {"n0": 3, "n1": 2},
+				"n1": {"n0": 1, "n1": 2},
+			},
+			want: VersionVector{},
+		},
+		{
+			name: "pointwise minimum",
+			observe: map[string]VersionVector{
+				"n0": {"n0": 3, "n1": 2, "n2": 1},
+				"n1": {"n0": 1, "n1": 2, "n2": 4},
+				"n2": {"n0": 2, "n1": 5},
+			},
+			want: VersionVector{"n0": 1, "n1": 2},
+		},
+		{
+			name: "untracked replica is ignored",
+			observe: map[string]VersionVector{
+				"n0": {"n0": 1},
+				"n1": {"n0": 1},
+				"n2": {"n0": 1},
+				"n9": {},
+			},
+			want: VersionVector{"n0": 1},
+		},
+		{
+			name: "forgotten replica no longer holds back",
+			observe: map[string]VersionVector{
+				"n0": {"n0": 2},
+				"n1": {"n0": 2},
+			},
+			forget: []string{"n2"},
+			want:   VersionVector{"n0": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewStabilityTracker([]string{"n0", "n1", "n2"})
+			for node, vv := range tt.observe {
+				tracker.Observe(node, vv)
+			}
+			for _, node := range tt.forget {
+				tracker.Forget(node)
+			}
+			if got := tracker.Stable(); !got.Equal(tt.want) {
+				t.Errorf("Stable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Observations only ever grow what a replica is known to have seen
+func TestStabilityTracker_Observe_Monotonic(t *testing.T) {
+	tracker := NewStabilityTracker([]string{"n0"})
+	tracker.Observe("n0", VersionVector{"n0": 3})
+	tracker.Observe("n0", VersionVector{"n0": 1, "n1": 1})
+	if got, want := tracker.Stable(), (VersionVector{"n0": 3, "n1": 1}); !got.Equal(want) {
+		t.Errorf("Stable() = %v, want %v", got, want)
+	}
+}
+
+func TestStabilityTracker_Track(t *testing.T) {
+	tracker := NewStabilityTracker(nil)
+	tracker.Observe("n0", VersionVector{"n0": 2})
+	tracker.Track("n0")
+	tracker.Track("n1")
+	tracker.Observe("n0", VersionVector{"n0": 2})
+	tracker.Observe("n1", VersionVector{"n0": 1})
+	// Tracking again keeps what was observed
+	tracker.Track("n0")
+	if got, want := tracker.Stable(), (VersionVector{"n0": 1}); !got.Equal(want) {
+		t.Errorf("Stable() = %v, want %v", got, want)
+	}
+}
+
+// syncORSets gossips full states between all replicas and reports their
+// clocks to a tracker, the way a node learns them from acknowledged gossip.
+func syncORSets(tracker *StabilityTracker, replicas map[string]ORSet[int]) {
+	for _, a := range replicas {
+		for _, b := range replicas {
+			a.Merge(b)
+		}
+	}
+	for node, c := range replicas {
+		tracker.Observe(node, c.Clock)
+	}
+}
+
+func TestORSet_Compact(t *testing.T) {
+	replicas := map[string]ORSet[int]{"n0": NewORSet[int](), "n1": NewORSet[int]()}
+	tracker := NewStabilityTracker([]string{"n0", "n1"})
+
+	replicas["n0"].Add("n0", 1)
+	replicas["n0"].Add("n0", 2)
+	stale := replicas["n0"].Copy()
+	replicas["n0"].Remove("n0", 1)
+
+	// Not everyone has seen the remove yet: the tombstone must stay
+	tracker.Observe("n0", replicas["n0"].Clock)
+	replicas["n0"].Compact(tracker.Stable())
+	if got := len(replicas["n0"].Removed); got != 1 {
+		t.Fatalf("len(Removed) before stability = %d, want 1", got)
+	}
+
+	syncORSets(tracker, replicas)
+	for node, c := range replicas {
+		c.Compact(tracker.Stable())
+		if got := len(c.Removed); got != 0 {
+			t.Errorf("%s: len(Removed) after Compact = %d, want 0", node, got)
+		}
+	}
+
+	// A stale state still holding the removed tag does not resurrect it, in
+	// either merge order
+	c := replicas["n1"].Copy()
+	c.Merge(stale)
+	if c.Contains(1) {
+		t.Errorf("compacted remove resurrected: Elements() = %v", sortedElements(c))
+	}
+	s := stale.Copy()
+	s.Merge(replicas["n1"])
+	if s.Contains(1) {
+		t.Errorf("compacted remove resurrected in stale state: Elements() = %v", sortedElements(s))
+	}
+	if got := sortedElements(c); len(got) != 1 || got[0] != 2 {
+		t.Errorf("Elements() = %v, want [2]", got)
+	}
+}
+
+// An add concurrent with a compacted remove still wins
+func TestORSet_Compact_AddWins(t *testing.T) {
+	replicas := map[string]ORSet[int]{"n0": NewORSet[int](), "n1": NewORSet[int]()}
+	tracker := NewStabilityTracker([]string{"n0", "n1"})
+	replicas["n0"].Add("n0", 1)
+	syncORSets(tracker, replicas)
+
+	replicas["n0"].Remove("n0", 1)
+	concurrent := replicas["n1"].Copy()
+	concurrent.Add("n1", 1)
+	syncORSets(tracker, replicas)
+	replicas["n0"].Compact(tracker.Stable())
+
+	replicas["n0"].Merge(concurrent)
+	if !replicas["n0"].Contains(1) {
+		t.Errorf("concurrent add lost after Compact: Elements() = %v", sortedElements(replicas["n0"]))
+	}
+}
+
+func TestPNCounter_Compact(t *testing.T) {
+	c := NewPNCounter[string, int]()
+	c.Increment("n0", 0)
+	c.Increment("n1", 3)
+	c.Increment("n2", -2)
+	want := c.Copy()
+
+	c.Compact()
+	if got := len(c.Positive) + len(c.Negative); got != 2 {
+		t.Errorf("entries after Compact = %d, want 2", got)
+	}
+	if !c.Equal(want) || c.Value() != 1 {
+		t.Errorf("Compact() changed the counter: got %v, want %v", c, want)
+	}
+}
@@ -0,0 +1,45 @@
+package crdt
+
+import "encoding/json"
+
+// StateCRDT is a state-based CRDT: replicas converge by exchanging whole
+// states (or delta-states) and merging them. Every type in this package also
+// implements json.Unmarshaler on its pointer, so states travel in message bodies.
+type StateCRDT[T any] interface {
+	Merge(other T)
+	Copy() T
+	Equal(other T) bool
+	json.Marshaler
+}
+
+var (
+	_ StateCRDT[GCounter[string, int]]     = GCounter[string, int]{}
+	_ StateCRDT[PNCounter[string, int]]    = PNCounter[string, int]{}
+	_ StateCRDT[GSet[int]]                 = GSet[int]{}
+	_ StateCRDT[ORSet[int]]                = ORSet[int]{}
+	_ StateCRDT[*RangeSet[int]]            = (*RangeSet[int])(nil)
+	_ StateCRDT[VersionVector]             = VersionVector{}
+	_ StateCRDT[*LWWRegister[int]]         = (*LWWRegister[int])(nil)
+	_ StateCRDT[LWWMap[string, int]]       = LWWMap[string, int]{}
+	_ StateCRDT[*MVRegister[int]]          = (*MVRegister[int])(nil)
+	_ StateCRDT[BoundedCounter]            = BoundedCounter{}
+	_ StateCRDT[*RGA[string]]              = (*RGA[string])(nil)
+	_ StateCRDT[EWFlag]                    = EWFlag{}
+	_ StateCRDT[DWFlag]                    = DWFlag{}
+	_ StateCRDT[*ORMap[string, GSet[int]]] = (*ORMap[string, GSet[int]])(nil)
+
+	_ json.Unmarshaler = (*GCounter[string, int])(nil)
+	_ json.Unmarshaler = (*PNCounter[string, int])(nil)
+	_ json.Unmarshaler = (*GSet[int])(nil)
+	_ json.Unmarshaler = (*ORSet[int])(nil)
+	_ json.Unmarshaler = (*RangeSet[int])(nil)
+	_ json.Unmarshaler = (*VersionVector)(nil)
+	_ json.Unmarshaler = (*LWWRegister[int])(nil)
+	_ json.Unmarshaler = (*LWWMap[string, int])(nil)
+	_ json.Unmarshaler = (*MVRegister[int])(nil)
+	_ json.Unmarshaler = (*BoundedCounter)(nil)
+	_ json.Unmarshaler = (*RGA[string])(nil)
+	_ json.Unmarshaler = (*EWFlag)(nil)
+	_ json.Unmarshaler = (*DWFlag)(nil)
+	_ json.Unmarshaler = (*ORMap[string, GSet[int]])(nil)
+)
@@ -0,0 +1,90 @@
+package crdt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// roundTrip encodes state and decodes it into a fresh value of the same type.
+func roundTrip[T StateCRDT[T]](t *testing.T, state T) T {
+	t.Helper()
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got T
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", data, err)
+	}
+	return got
+}
+
+// checkEqual verifies Equal is reflexive, survives a JSON round trip and a
+// Copy, and tells state apart from different.
+func checkEqual[T StateCRDT[T]](t *testing.T, state, different T) {
+	t.Helper()
+	if !state.Equal(state) {
+		t.Errorf("Equal() with itself = false")
+	}
+	if !state.Equal(state.Copy()) {
+		t.Errorf("Equal() with its Copy() = false")
+	}
+	if got := roundTrip(t, state); !state.Equal(got) || !got.Equal(state) {
+		t.Errorf("Equal() after JSON round trip = false")
+	}
+	if state.Equal(different) || different.Equal(state) {
+		t.Errorf("Equal() of different states = true")
+	}
+}
+
+func TestStateCRDT_Equal(t *testing.T) {
+	t.Run("GCounter", func(t *testing.T) {
+		checkEqual(t, GCounter[string, int]{"n0": 1}, GCounter[string, int]{"n0": 2})
+		if !(GCounter[string, int]{"n0": 0}).Equal(GCounter[string, int]{}) {
+			t.Errorf("Equal() must treat missing entries as zero")
+		}
+	})
+	t.Run("PNCounter", func(t *testing.T) {
+		a, b := NewPNCounter[string, int](), NewPNCounter[string, int]()
+		a.Increment("n0", 3)
+		b.Increment("n0", 3)
+		b.Increment("n0", -1)
+		checkEqual(t, a, b)
+	})
+	t.Run("GSet", func(t *testing.T) {
+		checkEqual(t, GSet[int]{1: {}}, GSet[int]{1: {}, 2: {}})
+	})
+	t.Run("ORSet", func(t *testing.T) {
+		a := NewORSet[int]()
+		a.Add("n0", 1)
+		b := a.Copy()
+		b.Remove("n0", 1)
+		checkEqual(t, a, b)
+	})
+	t.Run("RangeSet", func(t *testing.T) {
+		checkEqual(t, NewRangeSet(1, 2, 3), NewRangeSet(1, 3))
+	})
+	t.Run("VersionVector", func(t *testing.T) {
+		checkEqual(t, VersionVector{"n0": 1}, VersionVector{"n0": 1, "n1": 1})
+	})
+	t.Run("LWWRegister", func(t *testing.T) {
+		a, b := &LWWRegister[string]{}, &LWWRegister[string]{}
+		a.Set("x", at(1, "n0"))
+		b.Set("x", at(2, "n0"))
+		checkEqual(t, a, b)
+	})
+	t.Run("LWWMap", func(t *testing.T) {
+		a, b := LWWMap[string, int]{}, LWWMap[string, int]{}
+		a.Set("k", 1, at(1, "n0"))
+		b.Set("k", 1, at(1, "n0"))
+		b.Delete("k", at(2, "n0"))
+		checkEqual(t, a, b)
+	})
+	t.Run("MVRegister", func(t *testing.T) {
+		a := &MVRegister[string]{}
+		a.Set("n0", "x")
+		b := a.Copy()
+		b.Set("n1", "y")
+		checkEqual(t, a, b)
+	})
+}
@@ -0,0 +1,129 @@
+package crdt
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Dot identifies a single event issued by a node: the Seq-th operation of Node.
+type Dot struct {
+	Node string
+	Seq  int
+}
+
+// Less orders dots by sequence number, breaking ties by node ID.
+func (d Dot) Less(other Dot) bool {
+	if d.Seq != other.Seq {
+		return d.Seq < other.Seq
+	}
+	return d.Node < other.Node
+}
+
+func (d Dot) MarshalText() ([]byte, error) {
+	return []byte(d.Node + ":" + strconv.Itoa(d.Seq)), nil
+}
+
+func (d *Dot) UnmarshalText(text []byte) error {
+	s := string(text)
+	i := strings.LastIndex(s, ":")
+	if i == -1 {
+		return fmt.Errorf("invalid dot %q", s)
+	}
+	seq, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return fmt.Errorf("invalid dot %q: %w", s, err)
+	}
+	d.Node, d.Seq = s[:i], seq
+	return nil
+}
+
+type Ordering int
+
+const (
+	Equal Ordering = iota
+	Before
+	After
+	Concurrent
+)
+
+func (o Ordering) String() string {
+	switch o {
+	case Equal:
+		return "equal"
+	case Before:
+		return "before"
+	case After:
+		return "after"
+	case Concurrent:
+		return "concurrent"
+	}
+	return "Ordering(" + strconv.Itoa(int(o)) + ")"
+}
+
+// VersionVector counts, per node, how many events of that node are known.
+type VersionVector map[string]int
+
+// Increment records a new local event of node and returns its dot.
+func (v VersionVector) Increment(node string) Dot {
+	v[node]++
+	return Dot{Node: node, Seq: v[node]}
+}
+
+// Contains reports whether the event identified by d is known.
+func (v VersionVector) Contains(d Dot) bool {
+	return d.Seq <= v[d.Node]
+}
+
+func (v VersionVector) Merge(other VersionVector) {
+	for node, seq := range other {
+		v[node] = max(v[node], seq)
+	}
+}
+
+// Compare reports how v is causally related to other.
+func (v VersionVector) Compare(other VersionVector) Ordering {
+	less, greater := false, false
+	for node, seq := range v {
+		if seq > other[node] {
+			greater = true
+		}
+	}
+	for node, seq := range other {
+		if seq > v[node] {
+			less = true
+		}
+	}
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	}
+	return Equal
+}
+
+func (v VersionVector) Copy() VersionVector {
+	return maps.Clone(v)
+}
+
+func (v VersionVector) Equal(other VersionVector) bool {
+	return v.Compare(other) == Equal
+}
+
+func (v VersionVector) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]int(v))
+}
+
+func (v *VersionVector) UnmarshalJSON(data []byte) error {
+	var m map[string]int
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*v = m
+	return nil
+}
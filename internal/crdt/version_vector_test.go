@@ -0,0 +1,140 @@
+package crdt
+
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+)
+
+func TestVersionVector_Increment(t *testing.T) {
+	v := VersionVector{"n0": 2}
+	if got, want := v.Increment("n0"), (Dot{Node: "n0", Seq: 3}); got != want {
+		t.Errorf("Increment(n0) = %v, want %v", got, want)
+	}
+	if got, want := v.Increment("n1"), (Dot{Node: "n1", Seq: 1}); got != want {
+		t.Errorf("Increment(n1) = %v, want %v", got, want)
+	}
+	if want := (VersionVector{"n0": 3, "n1": 1}); !maps.Equal(v, want) {
+		t.Errorf("after Increment = %v, want %v", v, want)
+	}
+}
+
+func TestVersionVector_Contains(t *testing.T) {
+	v := VersionVector{"n0": 2}
+	tests := []struct {
+		dot  Dot
+		want bool
+	}{
+		{Dot{"n0", 1}, true},
+		{Dot{"n0", 2}, true},
+		{Dot{"n0", 3}, false},
+		{Dot{"n1", 1}, false},
+	}
+	for _, tt := range tests {
+		if got := v.Contains(tt.dot); got != tt.want {
+			t.Errorf("Contains(%v) = %v, want %v", tt.dot, got, tt.want)
+		}
+	}
+}
+
+func TestVersionVector_Compare(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     VersionVector
+		other VersionVector
+		want  Ordering
+	}{
+		{
+			name:  "both empty",
+			v:     VersionVector{},
+			other: VersionVector{},
+			want:  Equal,
+		},
+		{
+			name:  "missing entries count as zero",
+			v:     VersionVector{"n0": 0},
+			other: VersionVector{},
+			want:  Equal,
+		},
+		{
+			name:  "strictly behind",
+			v:     VersionVector{"n0": 1},
+			other: VersionVector{"n0": 2},
+			want:  Before,
+		},
+		{
+			name:  "behind on a node it has not seen",
+			v:     VersionVector{"n0": 2},
+			other: VersionVector{"n0": 2, "n1": 1},
+			want:  Before,
+		},
+		{
+			name:  "strictly ahead",
+			v:     VersionVector{"n0": 3, "n1": 1},
+			other: VersionVector{"n0": 2},
+			want:  After,
+		},
+		{
+			name:  "concurrent",
+			v:     VersionVector{"n0": 2, "n1": 1},
+			other: VersionVector{"n0": 1, "n1": 2},
+			want:  Concurrent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.v.Compare(tt.other); got != tt.want {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionVector_Merge(t *testing.T) {
+	v := VersionVector{"n0": 3, "n1": 1}
+	v.Merge(VersionVector{"n1": 4, "n2": 2})
+	if want := (VersionVector{"n0": 3, "n1": 4, "n2": 2}); !maps.Equal(v, want) {
+		t.Errorf("after Merge = %v, want %v", v, want)
+	}
+}
+
+func TestDot_Text(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Dot
+		wantErr bool
+	}{
+		{name: "simple", text: "n0:3", want: Dot{"n0", 3}},
+		{name: "node id with colon", text: "c:1:7", want: Dot{"c:1", 7}},
+		{name: "missing separator", text: "n0", wantErr: true},
+		{name: "non-numeric seq", text: "n0:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Dot
+			err := got.UnmarshalText([]byte(tt.text))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("UnmarshalText() = %v, want %v", got, tt.want)
+			}
+			if text, _ := got.MarshalText(); string(text) != tt.text {
+				t.Errorf("MarshalText() = %s, want %s", text, tt.text)
+			}
+		})
+	}
+}
+
+// Wire compatibility: a version vector encodes like the GCounter that ORSet used as its clock
+func TestVersionVector_JSON(t *testing.T) {
+	got, _ := json.Marshal(VersionVector{"n0": 1})
+	want, _ := json.Marshal(GCounter[string, int]{"n0": 1})
+	if string(got) != string(want) {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+// Package gossip holds the per-peer bookkeeping shared by the gossip nodes.
+package gossip
+
+import "math/rand/v2"
+
+// Inflight tracks the peers a send is outstanding to, so that a node retrying
+// a send to an unreachable peer does not start another one every tick. The
+// zero value is ready to use. It is not safe for concurrent use.
+type Inflight struct {
+	peers map[string]struct{}
+}
+
+// Start marks a send to peer outstanding. It reports false, and marks
+// nothing, if one already is.
+func (f *Inflight) Start(peer string) bool {
+	if _, ok := f.peers[peer]; ok {
+		return false
+	}
+	if f.peers == nil {
+		f.peers = make(map[string]struct{})
+	}
+	f.peers[peer] = struct{}{}
+	return true
+}
+
+// Done marks the send to peer finished, delivered or not.
+func (f *Inflight) Done(peer string) {
+	delete(f.peers, peer)
+}
+
+// Boot identifies one run of a node. Nodes send theirs along with their
+// gossip, so that peers notice when one restarted: it lost its state, and
+// whatever it acknowledged before must be sent again. Nodes from before boot
+// IDs send none, the zero Boot.
+type Boot uint64
+
+// NewBoot returns a random, non-zero boot ID.
+func NewBoot() Boot {
+	return Boot(rand.Uint64() | 1)
+}
+
+// Boots remembers the boot ID every peer last gossiped with. The zero value
+// is ready to use. It is not safe for concurrent use.
+type Boots struct {
+	boots map[string]Boot
+}
+
+// Restarted records boot as the current one of peer and reports whether peer
+// gossiped with another one before.
+func (b *Boots) Restarted(peer string, boot Boot) bool {
+	if boot == 0 {
+		return false
+	}
+	previous, ok := b.boots[peer]
+	if b.boots == nil {
+		b.boots = make(map[string]Boot)
+	}
+	b.boots[peer] = boot
+	return ok && previous != boot
+}
@@ -0,0 +1,51 @@
+package gossip
+
+import "testing"
+
+func TestInflight(t *testing.T) {
+	var f Inflight
+	if !f.Start("n1") {
+		t.Fatal("Start(n1) = false, want true")
+	}
+	if f.Start("n1") {
+		t.Error("Start(n1) while outstanding = true, want false")
+	}
+	if !f.Start("n2") {
+		t.Error("Start(n2) = false, want true")
+	}
+	f.Done("n1")
+	if !f.Start("n1") {
+		t.Error("Start(n1) after Done = false, want true")
+	}
+}
+
+func TestBoots_Restarted(t *testing.T) {
+	var b Boots
+	steps := []struct {
+		peer string
+		boot Boot
+		want bool
+	}{
+		{"n1", 1, false},
+		{"n1", 1, false},
+		{"n2", 2, false},
+		{"n1", 3, true},
+		{"n1", 3, false},
+		// Nodes without boot IDs are never taken for restarted
+		{"n1", 0, false},
+		{"n1", 3, false},
+	}
+	for i, step := range steps {
+		if got := b.Restarted(step.peer, step.boot); got != step.want {
+			t.Errorf("step %d: Restarted(%s, %d) = %v, want %v", i, step.peer, step.boot, got, step.want)
+		}
+	}
+}
+
+func TestNewBoot(t *testing.T) {
+	for range 100 {
+		if NewBoot() == 0 {
+			t.Fatal("NewBoot() = 0, want non-zero")
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package hlc
+
+import (
+	"cmp"
+	"sync"
+	"time"
+)
+
+// Timestamp is a hybrid logical clock reading: physical time in nanoseconds,
+// a logical counter for events within the same nanosecond and the issuing node
+// as the final tie-breaker, so timestamps from different nodes never compare equal.
+type Timestamp struct {
+	Wall    int64  `json:"wall"`
+	Logical int    `json:"logical"`
+	Node    string `json:"node"`
+}
+
+func (t Timestamp) Compare(other Timestamp) int {
+	if c := cmp.Compare(t.Wall, other.Wall); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(t.Logical, other.Logical); c != 0 {
+		return c
+	}
+	return cmp.Compare(t.Node, other.Node)
+}
+
+func (t Timestamp) Less(other Timestamp) bool {
+	return t.Compare(other) < 0
+}
+
+func (t Timestamp) IsZero() bool {
+	return t == Timestamp{}
+}
+
+type Clock struct {
+	mu   sync.Mutex
+	node string
+	now  func() time.Time
+	last Timestamp
+}
+
+// NewClock returns a clock for node reading physical time from now, or from
+// time.Now when now is nil.
+func NewClock(node string, now func() time.Time) *Clock {
+	if now == nil {
+		now = time.Now
+	}
+	return &Clock{
+		node: node,
+		now:  now,
+		last: Timestamp{Node: node},
+	}
+}
+
+// Now returns a timestamp for a local or send event, strictly greater than any
+// timestamp this clock returned before.
+func (c *Clock) Now() Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wall := c.now().UnixNano()
+	if wall > c.last.Wall {
+		c.last.Wall, c.last.Logical = wall, 0
+	} else {
+		c.last.Logical++
+	}
+	return c.last
+}
+
+// Update folds in a timestamp received from another node and returns a
+// timestamp for the receive event, greater than both remote and any timestamp
+// this clock returned before.
+func (c *Clock) Update(remote Timestamp) Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wall := max(c.now().UnixNano(), c.last.Wall, remote.Wall)
+	switch {
+	case wall == c.last.Wall && wall == remote.Wall:
+		c.last.Logical = max(c.last.Logical, remote.Logical) + 1
+	case wall == c.last.Wall:
+		c.last.Logical++
+	case wall == remote.Wall:
+		c.last.Logical = remote.Logical + 1
+	default:
+		c.last.Logical = 0
+	}
+	c.last.Wall = wall
+	return c.last
+}
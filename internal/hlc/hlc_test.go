@@ -0,0 +1,137 @@
+package hlc
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return f.t }
+
+func (f *fakeClock) Advance(d time.Duration) { f.t = f.t.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Unix(1000, 0)}
+}
+
+func TestTimestamp_Compare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Timestamp
+		want int
+	}{
+		{
+			name: "wall time dominates",
+			a:    Timestamp{Wall: 1, Logical: 9, Node: "n9"},
+			b:    Timestamp{Wall: 2, Logical: 0, Node: "n0"},
+			want: -1,
+		},
+		{
+			name: "logical breaks wall tie",
+			a:    Timestamp{Wall: 2, Logical: 1, Node: "n0"},
+			b:    Timestamp{Wall: 2, Logical: 0, Node: "n9"},
+			want: 1,
+		},
+		{
+			name: "node breaks full tie",
+			a:    Timestamp{Wall: 2, Logical: 1, Node: "n0"},
+			b:    Timestamp{Wall: 2, Logical: 1, Node: "n1"},
+			want: -1,
+		},
+		{
+			name: "equal",
+			a:    Timestamp{Wall: 2, Logical: 1, Node: "n0"},
+			b:    Timestamp{Wall: 2, Logical: 1, Node: "n0"},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Compare(tt.b); got != tt.want {
+				t.Errorf("Compare() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClock_Now(t *testing.T) {
+	fake := newFakeClock()
+	c := NewClock("n0", fake.Now)
+
+	first := c.Now()
+	if first.Wall != fake.t.UnixNano() || first.Logical != 0 || first.Node != "n0" {
+		t.Errorf("Now() = %+v, want wall=%d logical=0 node=n0", first, fake.t.UnixNano())
+	}
+
+	// Physical time standing still bumps the logical counter
+	second := c.Now()
+	if !first.Less(second) || second.Logical != 1 {
+		t.Errorf("Now() without time passing = %+v, want logical=1 after %+v", second, first)
+	}
+
+	// Physical time going backwards never moves the clock backwards
+	fake.Advance(-time.Second)
+	third := c.Now()
+	if !second.Less(third) {
+		t.Errorf("Now() after clock skew = %+v, want > %+v", third, second)
+	}
+
+	// Physical time moving forward resets the logical counter
+	fake.Advance(2 * time.Second)
+	fourth := c.Now()
+	if fourth.Wall != fake.t.UnixNano() || fourth.Logical != 0 {
+		t.Errorf("Now() after time passed = %+v, want wall=%d logical=0", fourth, fake.t.UnixNano())
+	}
+}
+
+func TestClock_Update(t *testing.T) {
+	tests := []struct {
+		name        string
+		local       time.Duration
+		remote      Timestamp
+		wantWall    int64
+		wantLogical int
+	}{
+		{
+			name:        "local physical time ahead",
+			local:       time.Second,
+			remote:      Timestamp{Wall: time.Unix(1000, 0).UnixNano(), Logical: 5, Node: "n1"},
+			wantWall:    time.Unix(1001, 0).UnixNano(),
+			wantLogical: 0,
+		},
+		{
+			name:        "remote ahead",
+			remote:      Timestamp{Wall: time.Unix(1005, 0).UnixNano(), Logical: 5, Node: "n1"},
+			wantWall:    time.Unix(1005, 0).UnixNano(),
+			wantLogical: 6,
+		},
+		{
+			name:        "remote and last local share wall time",
+			remote:      Timestamp{Wall: time.Unix(1000, 0).UnixNano(), Logical: 3, Node: "n1"},
+			wantWall:    time.Unix(1000, 0).UnixNano(),
+			wantLogical: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeClock()
+			c := NewClock("n0", fake.Now)
+			c.Now()
+			fake.Advance(tt.local)
+
+			got := c.Update(tt.remote)
+			if got.Wall != tt.wantWall || got.Logical != tt.wantLogical || got.Node != "n0" {
+				t.Errorf("Update() = %+v, want wall=%d logical=%d node=n0", got, tt.wantWall, tt.wantLogical)
+			}
+			if !tt.remote.Less(got) {
+				t.Errorf("Update() = %+v, want > remote %+v", got, tt.remote)
+			}
+			if next := c.Now(); !got.Less(next) {
+				t.Errorf("Now() after Update = %+v, want > %+v", next, got)
+			}
+		})
+	}
+}
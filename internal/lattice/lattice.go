@@ -0,0 +1,80 @@
+// Package lattice provides join-semilattices that compose into state-based
+// CRDTs. Joins never modify their operands, so a result may share structure
+// with them; treat values as immutable once joined.
+//
+// A grow-only counter, for example, is a MapOf[string, Max[int]] summed over
+// its entries, and a PN-counter is a Pair of two of them.
+package lattice
+
+import (
+	"cmp"
+	"maps"
+)
+
+// Lattice is a join-semilattice: Join returns the least upper bound of both
+// values and is commutative, associative and idempotent.
+type Lattice[T any] interface {
+	Join(other T) T
+}
+
+// Max is ordered by value, joins keep the larger one.
+type Max[T cmp.Ordered] struct {
+	Value T
+}
+
+func (m Max[T]) Join(other Max[T]) Max[T] {
+	return Max[T]{Value: max(m.Value, other.Value)}
+}
+
+// Min is ordered in reverse, joins keep the smaller value.
+type Min[T cmp.Ordered] struct {
+	Value T
+}
+
+func (m Min[T]) Join(other Min[T]) Min[T] {
+	return Min[T]{Value: min(m.Value, other.Value)}
+}
+
+// Or is a flag that once set stays set.
+type Or bool
+
+func (b Or) Join(other Or) Or {
+	return b || other
+}
+
+// Union is a grow-only set, joins are unions.
+type Union[T comparable] map[T]struct{}
+
+func (s Union[T]) Join(other Union[T]) Union[T] {
+	joined := make(Union[T], max(len(s), len(other)))
+	maps.Copy(joined, s)
+	maps.Copy(joined, other)
+	return joined
+}
+
+// Pair is the product of two lattices, joined component-wise.
+type Pair[A Lattice[A], B Lattice[B]] struct {
+	First  A
+	Second B
+}
+
+func (p Pair[A, B]) Join(other Pair[A, B]) Pair[A, B] {
+	return Pair[A, B]{First: p.First.Join(other.First), Second: p.Second.Join(other.Second)}
+}
+
+// MapOf maps keys to lattices, joined key by key. A missing key is the
+// bottom of V, so joining with it yields the present value.
+type MapOf[K comparable, V Lattice[V]] map[K]V
+
+func (m MapOf[K, V]) Join(other MapOf[K, V]) MapOf[K, V] {
+	joined := make(MapOf[K, V], max(len(m), len(other)))
+	maps.Copy(joined, m)
+	for key, val := range other {
+		if cur, ok := joined[key]; ok {
+			joined[key] = cur.Join(val)
+		} else {
+			joined[key] = val
+		}
+	}
+	return joined
+}
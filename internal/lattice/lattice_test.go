@@ -0,0 +1,163 @@
+package lattice
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"gossip-glomers/internal/crdt"
+	"gossip-glomers/internal/crdt/crdttest"
+)
+
+// replica adapts a lattice to the Merge and Copy of crdttest. Copies may share
+// structure since joins never modify their operands.
+type replica[T Lattice[T]] struct {
+	value T
+}
+
+func (r *replica[T]) Merge(other *replica[T]) { r.value = r.value.Join(other.value) }
+
+func (r *replica[T]) Copy() *replica[T] { return &replica[T]{value: r.value} }
+
+// checkLaws checks the semilattice laws with crdttest on histories that start
+// from bottom and join values from gen.
+func checkLaws[T Lattice[T]](t *testing.T, bottom T, gen func(r *rand.Rand) T) {
+	t.Helper()
+	crdttest.Run(t, crdttest.Config[*replica[T]]{
+		New:   func() *replica[T] { return &replica[T]{value: bottom} },
+		Op:    func(r *rand.Rand, _ string, state *replica[T]) { state.value = state.value.Join(gen(r)) },
+		Equal: func(a, b *replica[T]) bool { return reflect.DeepEqual(a.value, b.value) },
+	})
+}
+
+func genMax(r *rand.Rand) Max[int] { return Max[int]{Value: r.IntN(100) - 50} }
+
+func genUnion(r *rand.Rand) Union[int] {
+	s := Union[int]{}
+	for range r.IntN(5) {
+		s[r.IntN(10)] = struct{}{}
+	}
+	return s
+}
+
+func genMapOf[V Lattice[V]](gen func(r *rand.Rand) V) func(r *rand.Rand) MapOf[string, V] {
+	return func(r *rand.Rand) MapOf[string, V] {
+		m := MapOf[string, V]{}
+		for range r.IntN(4) {
+			m[fmt.Sprint("n", r.IntN(4))] = gen(r)
+		}
+		return m
+	}
+}
+
+func TestLaws(t *testing.T) {
+	t.Run("Max", func(t *testing.T) { checkLaws(t, Max[int]{}, genMax) })
+	t.Run("Min", func(t *testing.T) {
+		// "~" sorts after every generated value, so it is the bottom
+		checkLaws(t, Min[string]{Value: "~"}, func(r *rand.Rand) Min[string] { return Min[string]{Value: fmt.Sprint(r.IntN(100))} })
+	})
+	t.Run("Or", func(t *testing.T) {
+		checkLaws(t, false, func(r *rand.Rand) Or { return r.IntN(2) == 0 })
+	})
+	t.Run("Union", func(t *testing.T) { checkLaws(t, Union[int]{}, genUnion) })
+	t.Run("Pair", func(t *testing.T) {
+		checkLaws(t, Pair[Max[int], Union[int]]{Second: Union[int]{}}, func(r *rand.Rand) Pair[Max[int], Union[int]] {
+			return Pair[Max[int], Union[int]]{First: genMax(r), Second: genUnion(r)}
+		})
+	})
+	t.Run("MapOf", func(t *testing.T) { checkLaws(t, MapOf[string, Max[int]]{}, genMapOf(genMax)) })
+	t.Run("nested", func(t *testing.T) {
+		checkLaws(t, MapOf[string, Pair[Or, MapOf[string, Min[int]]]]{}, genMapOf(func(r *rand.Rand) Pair[Or, MapOf[string, Min[int]]] {
+			return Pair[Or, MapOf[string, Min[int]]]{
+				First:  r.IntN(2) == 0,
+				Second: genMapOf(func(r *rand.Rand) Min[int] { return Min[int]{Value: r.IntN(10)} })(r),
+			}
+		}))
+	})
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"Max", Max[int]{3}.Join(Max[int]{5}), Max[int]{5}},
+		{"Min", Min[int]{3}.Join(Min[int]{5}), Min[int]{3}},
+		{"Or", Or(false).Join(true), Or(true)},
+		{"Union", Union[int]{1: {}}.Join(Union[int]{2: {}}), Union[int]{1: {}, 2: {}}},
+		{
+			"Pair",
+			Pair[Max[int], Min[int]]{Max[int]{1}, Min[int]{1}}.Join(Pair[Max[int], Min[int]]{Max[int]{2}, Min[int]{2}}),
+			Pair[Max[int], Min[int]]{Max[int]{2}, Min[int]{1}},
+		},
+		{
+			"MapOf",
+			MapOf[string, Max[int]]{"a": {1}, "b": {5}}.Join(MapOf[string, Max[int]]{"b": {3}, "c": {2}}),
+			MapOf[string, Max[int]]{"a": {1}, "b": {5}, "c": {2}},
+		},
+		{"MapOf with nil", MapOf[string, Max[int]](nil).Join(nil), MapOf[string, Max[int]]{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("Join() = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoin_LeavesOperandsUntouched(t *testing.T) {
+	a := MapOf[string, Max[int]]{"a": {1}}
+	b := MapOf[string, Max[int]]{"a": {2}}
+	a.Join(b)
+	if a["a"].Value != 1 || b["a"].Value != 2 {
+		t.Errorf("Join() modified its operands: a=%v b=%v", a, b)
+	}
+}
+
+type gCounter = MapOf[string, Max[int]]
+
+func sum(c gCounter) int {
+	total := 0
+	for _, v := range c {
+		total += v.Value
+	}
+	return total
+}
+
+// GCounter and PNCounter are derivable from the combinators: under the same
+// random histories the derived counters read the same values
+func TestDerivedCounters(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	nodes := []string{"n0", "n1", "n2"}
+	counters := make([]crdt.PNCounter[string, int], len(nodes))
+	derived := make([]Pair[gCounter, gCounter], len(nodes))
+	for i := range nodes {
+		counters[i] = crdt.NewPNCounter[string, int]()
+		derived[i] = Pair[gCounter, gCounter]{gCounter{}, gCounter{}}
+	}
+
+	for range 500 {
+		i := r.IntN(len(nodes))
+		node := nodes[i]
+		if r.IntN(3) == 0 {
+			j := r.IntN(len(nodes))
+			counters[i].Merge(counters[j])
+			derived[i] = derived[i].Join(derived[j])
+		} else {
+			delta := r.IntN(21) - 10
+			counters[i].Increment(node, delta)
+			d := &derived[i]
+			if delta >= 0 {
+				d.First = d.First.Join(gCounter{node: {Value: d.First[node].Value + delta}})
+			} else {
+				d.Second = d.Second.Join(gCounter{node: {Value: d.Second[node].Value - delta}})
+			}
+		}
+		if got, want := sum(derived[i].First)-sum(derived[i].Second), counters[i].Value(); got != want {
+			t.Fatalf("derived counter of %s = %d, want %d", node, got, want)
+		}
+	}
+}
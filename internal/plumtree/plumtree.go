@@ -0,0 +1,341 @@
+// Package plumtree implements the Plumtree epidemic broadcast tree protocol
+// (Leitão, Pereira and Rodrigues, 2007). Every node starts out pushing each
+// value eagerly to all its peers. Duplicates prune the links they arrive on
+// to lazy ones, which leaves the eager links a spanning tree. Lazy peers are
+// only told which values a node has, in batched IHAVE announcements. A node
+// that hears of a value it has not received in time grafts the link it heard
+// of it on back into the tree, which is how the tree heals around failures.
+//
+// The protocol does not retransmit: a value whose gossip and announcements
+// are all lost, say in a partition, is not recovered. Pair it with an
+// anti-entropy protocol for that.
+//
+// Peers that stop answering are dropped from the tree once they failed
+// Config.SuspectAfter times in a row, so its links are grafted around them.
+// Grafts that time out count as failures; pushes that time out do if the
+// caller reports them with Failed.
+package plumtree
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindGossip Kind = "gossip"
+	KindIHave  Kind = "ihave"
+	KindGraft  Kind = "graft"
+	KindPrune  Kind = "prune"
+)
+
+// Message is a protocol message between peers. Values identify themselves,
+// so they must be unique.
+type Message[T comparable] struct {
+	Kind Kind `json:"kind"`
+	// Value is the value gossiped or grafted.
+	Value T `json:"value"`
+	// Round is the number of hops Value has taken from its origin.
+	Round int               `json:"round"`
+	IHave []Announcement[T] `json:"ihave,omitempty"`
+}
+
+// Announcement tells a lazy peer that a value has been received.
+type Announcement[T comparable] struct {
+	Value T   `json:"value"`
+	Round int `json:"round"`
+}
+
+// Send delivers msg to peer, or loses it. It must not block for long and
+// must not call back into the node.
+type Send[T comparable] func(peer string, msg Message[T])
+
+type Config struct {
+	// GraftTimeout is how long a node waits for a value it heard of before
+	// grafting the peer it heard of it from.
+	GraftTimeout time.Duration
+	// RetryTimeout is how long it waits after a graft before grafting the
+	// next peer that announced the value.
+	RetryTimeout time.Duration
+	// SuspectAfter is the number of failures in a row after which a peer is
+	// dropped, as if by NeighbourDown, until it is reached again. 0 never
+	// drops peers.
+	SuspectAfter int
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+type Node[T comparable] struct {
+	mu       sync.Mutex
+	send     Send[T]
+	deliver  func(T)
+	config   Config
+	eager    map[string]struct{}
+	lazy     map[string]struct{}
+	received map[T]struct{}
+	missing  map[T]*missing
+	// lazyQueue collects the announcements to each lazy peer until Tick
+	lazyQueue map[string][]Announcement[T]
+	// failures counts the failures of each peer since it was last reached;
+	// suspected are the peers dropped for them
+	failures  map[string]int
+	suspected map[string]struct{}
+
+	// Messages and deliveries are collected under mu and handed out after
+	// it is released, so send and deliver may take their time
+	outbox    []envelope[T]
+	delivered []T
+}
+
+// missing is a value a node heard of but has not received.
+type missing struct {
+	announcers []announcer
+	deadline   time.Time
+	// grafted is the peer last grafted for the value, if any
+	grafted string
+}
+
+type announcer struct {
+	peer  string
+	round int
+}
+
+type envelope[T comparable] struct {
+	peer string
+	msg  Message[T]
+}
+
+// NewNode returns a node that pushes eagerly to all of peers. It hands values
+// it receives for the first time to deliver.
+func NewNode[T comparable](peers []string, send Send[T], deliver func(T), config Config) *Node[T] {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	n := &Node[T]{
+		send:      send,
+		deliver:   deliver,
+		config:    config,
+		eager:     make(map[string]struct{}, len(peers)),
+		lazy:      make(map[string]struct{}, len(peers)),
+		received:  make(map[T]struct{}),
+		missing:   make(map[T]*missing),
+		lazyQueue: make(map[string][]Announcement[T]),
+		failures:  make(map[string]int),
+		suspected: make(map[string]struct{}),
+	}
+	for _, peer := range peers {
+		n.eager[peer] = struct{}{}
+	}
+	return n
+}
+
+// Broadcast delivers v and spreads it to all nodes, unless it has been
+// received before. It reports whether v was new.
+func (n *Node[T]) Broadcast(v T) bool {
+	n.mu.Lock()
+	_, seen := n.received[v]
+	if !seen {
+		n.accept(v, 0, "")
+	}
+	n.flush()
+	return !seen
+}
+
+// Receive handles msg from peer.
+func (n *Node[T]) Receive(from string, msg Message[T]) {
+	n.mu.Lock()
+	n.reach(from)
+	switch msg.Kind {
+	case KindGossip:
+		if _, ok := n.received[msg.Value]; ok {
+			// A duplicate: the link is redundant
+			n.setLazy(from)
+			n.out(from, Message[T]{Kind: KindPrune})
+		} else {
+			n.setEager(from)
+			n.accept(msg.Value, msg.Round, from)
+		}
+	case KindIHave:
+		for _, a := range msg.IHave {
+			if _, ok := n.received[a.Value]; ok {
+				continue
+			}
+			m, ok := n.missing[a.Value]
+			if !ok {
+				m = &missing{deadline: n.config.Now().Add(n.config.GraftTimeout)}
+				n.missing[a.Value] = m
+			}
+			m.announcers = append(m.announcers, announcer{peer: from, round: a.Round})
+		}
+	case KindGraft:
+		n.setEager(from)
+		if _, ok := n.received[msg.Value]; ok {
+			n.out(from, Message[T]{Kind: KindGossip, Value: msg.Value, Round: msg.Round})
+		}
+	case KindPrune:
+		n.setLazy(from)
+	}
+	n.flush()
+}
+
+// Tick grafts the links to values that are overdue and sends the queued
+// announcements. A graft that did not bring the value in time is a failure of
+// the grafted peer. Call it periodically, more often than GraftTimeout.
+func (n *Node[T]) Tick() {
+	n.mu.Lock()
+	now := n.config.Now()
+	for v, m := range n.missing {
+		if now.Before(m.deadline) {
+			continue
+		}
+		if m.grafted != "" {
+			n.fail(m.grafted)
+			m.grafted = ""
+		}
+		if len(m.announcers) == 0 {
+			// Everyone that announced v has been grafted without result
+			delete(n.missing, v)
+			continue
+		}
+		a := m.announcers[0]
+		m.announcers = m.announcers[1:]
+		m.deadline = now.Add(n.config.RetryTimeout)
+		m.grafted = a.peer
+		n.setEager(a.peer)
+		n.out(a.peer, Message[T]{Kind: KindGraft, Value: v, Round: a.round})
+	}
+	for _, peer := range slices.Sorted(maps.Keys(n.lazyQueue)) {
+		n.out(peer, Message[T]{Kind: KindIHave, IHave: n.lazyQueue[peer]})
+	}
+	clear(n.lazyQueue)
+	n.flush()
+}
+
+// NeighbourUp adds peer as an eager peer.
+func (n *Node[T]) NeighbourUp(peer string) {
+	n.mu.Lock()
+	n.setEager(peer)
+	n.flush()
+}
+
+// NeighbourDown forgets peer, including what it announced.
+func (n *Node[T]) NeighbourDown(peer string) {
+	n.mu.Lock()
+	n.down(peer)
+	n.flush()
+}
+
+// Failed records that peer did not answer, e.g. that a push to it timed out.
+// It reports whether this dropped peer.
+func (n *Node[T]) Failed(peer string) bool {
+	n.mu.Lock()
+	dropped := n.fail(peer)
+	n.flush()
+	return dropped
+}
+
+// Reached records that peer answered. It reports whether this took peer back
+// after it had been dropped for failing. Receiving from peer reaches it too.
+func (n *Node[T]) Reached(peer string) bool {
+	n.mu.Lock()
+	recovered := n.reach(peer)
+	n.flush()
+	return recovered
+}
+
+// EagerPeers returns the peers values are pushed to, in order.
+func (n *Node[T]) EagerPeers() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return slices.Sorted(maps.Keys(n.eager))
+}
+
+// LazyPeers returns the peers values are announced to, in order.
+func (n *Node[T]) LazyPeers() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return slices.Sorted(maps.Keys(n.lazy))
+}
+
+// accept delivers v, received round hops from its origin through from, and
+// passes it on to all other peers. from is empty for values broadcast here.
+func (n *Node[T]) accept(v T, round int, from string) {
+	n.received[v] = struct{}{}
+	delete(n.missing, v)
+	n.delivered = append(n.delivered, v)
+	for _, peer := range slices.Sorted(maps.Keys(n.eager)) {
+		if peer != from {
+			n.out(peer, Message[T]{Kind: KindGossip, Value: v, Round: round + 1})
+		}
+	}
+	for peer := range n.lazy {
+		if peer != from {
+			n.lazyQueue[peer] = append(n.lazyQueue[peer], Announcement[T]{Value: v, Round: round + 1})
+		}
+	}
+}
+
+func (n *Node[T]) down(peer string) {
+	delete(n.eager, peer)
+	delete(n.lazy, peer)
+	delete(n.lazyQueue, peer)
+	for _, m := range n.missing {
+		m.announcers = slices.DeleteFunc(m.announcers, func(a announcer) bool { return a.peer == peer })
+	}
+}
+
+func (n *Node[T]) fail(peer string) bool {
+	if _, ok := n.suspected[peer]; ok || n.config.SuspectAfter == 0 {
+		return false
+	}
+	n.failures[peer]++
+	if n.failures[peer] < n.config.SuspectAfter {
+		return false
+	}
+	delete(n.failures, peer)
+	n.suspected[peer] = struct{}{}
+	n.down(peer)
+	return true
+}
+
+func (n *Node[T]) reach(peer string) bool {
+	delete(n.failures, peer)
+	if _, ok := n.suspected[peer]; !ok {
+		return false
+	}
+	delete(n.suspected, peer)
+	n.setEager(peer)
+	return true
+}
+
+func (n *Node[T]) setEager(peer string) {
+	delete(n.lazy, peer)
+	n.eager[peer] = struct{}{}
+}
+
+func (n *Node[T]) setLazy(peer string) {
+	delete(n.eager, peer)
+	n.lazy[peer] = struct{}{}
+}
+
+func (n *Node[T]) out(peer string, msg Message[T]) {
+	n.outbox = append(n.outbox, envelope[T]{peer: peer, msg: msg})
+}
+
+// flush releases mu, then sends the collected messages and hands out the
+// collected deliveries.
+func (n *Node[T]) flush() {
+	outbox, delivered := n.outbox, n.delivered
+	n.outbox, n.delivered = nil, nil
+	n.mu.Unlock()
+
+	for _, v := range delivered {
+		n.deliver(v)
+	}
+	for _, e := range outbox {
+		n.send(e.peer, e.msg)
+	}
+}
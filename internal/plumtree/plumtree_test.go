@@ -0,0 +1,380 @@
+package plumtree
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"gossip-glomers/internal/topology"
+)
+
+// network simulates nodes exchanging messages in lockstep: every step
+// delivers the messages sent in the step before, advances the clock and ticks
+// every node. Messages to and from crashed or partitioned nodes are lost, and
+// gossip lost that way is reported to its sender as a timed out push.
+// Partitioned nodes keep ticking.
+type network struct {
+	nodes       map[string]*Node[int]
+	delivered   map[string][]int
+	queue       []delivery
+	crashed     map[string]bool
+	partitioned map[string]bool
+	now         time.Time
+	sent        map[Kind]int
+}
+
+type delivery struct {
+	from, to string
+	msg      Message[int]
+}
+
+const step = 10 * time.Millisecond
+
+func newNetwork(t *testing.T, n, suspectAfter int) *network {
+	t.Helper()
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = fmt.Sprint("n", i)
+	}
+	overlay, err := topology.NewRandomRegular(nodes, 4, 1)
+	if err != nil {
+		t.Fatalf("NewRandomRegular() error = %v", err)
+	}
+	net := &network{
+		nodes:       map[string]*Node[int]{},
+		delivered:   map[string][]int{},
+		crashed:     map[string]bool{},
+		partitioned: map[string]bool{},
+		now:         time.Unix(0, 0),
+		sent:        map[Kind]int{},
+	}
+	config := Config{GraftTimeout: 10 * step, RetryTimeout: 5 * step, SuspectAfter: suspectAfter, Now: func() time.Time { return net.now }}
+	for _, node := range nodes {
+		send := func(peer string, msg Message[int]) {
+			net.sent[msg.Kind]++
+			net.queue = append(net.queue, delivery{from: node, to: peer, msg: msg})
+		}
+		deliver := func(v int) { net.delivered[node] = append(net.delivered[node], v) }
+		net.nodes[node] = NewNode(overlay.Peers(node), send, deliver, config)
+	}
+	return net
+}
+
+func (net *network) run(steps int) {
+	for range steps {
+		queue := net.queue
+		net.queue = nil
+		for _, d := range queue {
+			switch {
+			case net.reachable(d.from) && net.reachable(d.to):
+				net.nodes[d.to].Receive(d.from, d.msg)
+			case !net.crashed[d.from] && d.msg.Kind == KindGossip:
+				net.nodes[d.from].Failed(d.to)
+			}
+		}
+		net.now = net.now.Add(step)
+		for name, node := range net.nodes {
+			if !net.crashed[name] {
+				node.Tick()
+			}
+		}
+	}
+}
+
+func (net *network) reachable(node string) bool {
+	return !net.crashed[node] && !net.partitioned[node]
+}
+
+// checkDelivered fails unless every reachable node delivered exactly values.
+func (net *network) checkDelivered(t *testing.T, values []int) {
+	t.Helper()
+	for name := range net.nodes {
+		if !net.reachable(name) {
+			continue
+		}
+		got := slices.Sorted(slices.Values(net.delivered[name]))
+		if !reflect.DeepEqual(got, values) {
+			t.Fatalf("%s delivered %v, want %v", name, got, values)
+		}
+	}
+}
+
+func TestPlumtree_Broadcast(t *testing.T) {
+	net := newNetwork(t, 25, 0)
+	var values []int
+	for v := range 30 {
+		net.nodes[fmt.Sprint("n", v%25)].Broadcast(v)
+		values = append(values, v)
+		net.run(40)
+	}
+	net.checkDelivered(t, values)
+
+	// The eager links have been pruned down to a spanning tree, over which a
+	// broadcast takes one message per node
+	clear(net.sent)
+	net.nodes["n7"].Broadcast(100)
+	net.run(40)
+	net.checkDelivered(t, append(values, 100))
+	if got := net.sent[KindGossip]; got != 24 {
+		t.Errorf("broadcast over settled tree sent %d gossip messages, want 24", got)
+	}
+	if got := net.sent[KindGraft]; got != 0 {
+		t.Errorf("broadcast over settled tree sent %d grafts, want 0", got)
+	}
+}
+
+func TestPlumtree_HealsAroundFailure(t *testing.T) {
+	net := newNetwork(t, 25, 0)
+	var values []int
+	for v := range 10 {
+		net.nodes[fmt.Sprint("n", v)].Broadcast(v)
+		values = append(values, v)
+		net.run(40)
+	}
+
+	// Crash the node relaying to the most peers without telling anyone
+	relay := "n0"
+	for name, node := range net.nodes {
+		if len(node.EagerPeers()) > len(net.nodes[relay].EagerPeers()) {
+			relay = name
+		}
+	}
+	net.crashed[relay] = true
+	clear(net.sent)
+	for v := 10; v < 30; v++ {
+		origin := fmt.Sprint("n", v%25)
+		if origin == relay {
+			continue
+		}
+		net.nodes[origin].Broadcast(v)
+		values = append(values, v)
+		net.run(40)
+	}
+	net.checkDelivered(t, values)
+	if net.sent[KindGraft] == 0 {
+		t.Errorf("no grafts sent after %s crashed", relay)
+	}
+}
+
+func TestPlumtree_DropsPartitionedPeer(t *testing.T) {
+	net := newNetwork(t, 25, 2)
+	var values []int
+	for v := range 10 {
+		net.nodes[fmt.Sprint("n", v)].Broadcast(v)
+		values = append(values, v)
+		net.run(40)
+	}
+
+	// Cut off the node relaying to the most peers. Its peers notice from
+	// the pushes that time out
+	relay := "n0"
+	for name, node := range net.nodes {
+		if len(node.EagerPeers()) > len(net.nodes[relay].EagerPeers()) {
+			relay = name
+		}
+	}
+	net.partitioned[relay] = true
+	for v := 10; v < 30; v++ {
+		origin := fmt.Sprint("n", v%25)
+		if origin == relay {
+			continue
+		}
+		net.nodes[origin].Broadcast(v)
+		values = append(values, v)
+		net.run(40)
+	}
+	net.checkDelivered(t, values)
+	for name, node := range net.nodes {
+		if name != relay && slices.Contains(node.EagerPeers(), relay) {
+			t.Errorf("%s still pushes to partitioned %s", name, relay)
+		}
+	}
+
+	// Values now reach everyone else over the tree alone, without grafts
+	clear(net.sent)
+	origin := "n0"
+	if origin == relay {
+		origin = "n1"
+	}
+	net.nodes[origin].Broadcast(100)
+	net.run(40)
+	net.checkDelivered(t, append(values, 100))
+	if got := net.sent[KindGraft]; got != 0 {
+		t.Errorf("broadcast over repaired tree sent %d grafts, want 0", got)
+	}
+}
+
+// recorder is a node whose sent messages and deliveries are kept for
+// inspection.
+type recorder struct {
+	node      *Node[int]
+	sent      []delivery
+	delivered []int
+	now       time.Time
+}
+
+func newRecorder(peers ...string) *recorder {
+	r := &recorder{now: time.Unix(0, 0)}
+	send := func(peer string, msg Message[int]) { r.sent = append(r.sent, delivery{to: peer, msg: msg}) }
+	deliver := func(v int) { r.delivered = append(r.delivered, v) }
+	r.node = NewNode(peers, send, deliver, Config{GraftTimeout: time.Second, RetryTimeout: time.Second / 2, Now: func() time.Time { return r.now }})
+	return r
+}
+
+// take returns the messages sent since the last call.
+func (r *recorder) take() []delivery {
+	sent := r.sent
+	r.sent = nil
+	return sent
+}
+
+func TestNode_Messages(t *testing.T) {
+	gossip := func(v, round int) Message[int] { return Message[int]{Kind: KindGossip, Value: v, Round: round} }
+
+	t.Run("broadcast pushes to all eager peers", func(t *testing.T) {
+		r := newRecorder("a", "b")
+		if !r.node.Broadcast(1) {
+			t.Fatalf("Broadcast() = false, want true")
+		}
+		want := []delivery{{to: "a", msg: gossip(1, 1)}, {to: "b", msg: gossip(1, 1)}}
+		if got := r.take(); !reflect.DeepEqual(got, want) {
+			t.Errorf("sent %v, want %v", got, want)
+		}
+		if r.node.Broadcast(1) {
+			t.Errorf("Broadcast() of a received value = true, want false")
+		}
+		if !reflect.DeepEqual(r.delivered, []int{1}) {
+			t.Errorf("delivered %v, want [1]", r.delivered)
+		}
+	})
+
+	t.Run("gossip is passed on, duplicates prune", func(t *testing.T) {
+		r := newRecorder("a", "b", "c")
+		r.node.Receive("a", gossip(1, 2))
+		want := []delivery{{to: "b", msg: gossip(1, 3)}, {to: "c", msg: gossip(1, 3)}}
+		if got := r.take(); !reflect.DeepEqual(got, want) {
+			t.Errorf("sent %v, want %v", got, want)
+		}
+		r.node.Receive("b", gossip(1, 2))
+		want = []delivery{{to: "b", msg: Message[int]{Kind: KindPrune}}}
+		if got := r.take(); !reflect.DeepEqual(got, want) {
+			t.Errorf("sent %v, want %v", got, want)
+		}
+		if got := r.node.LazyPeers(); !reflect.DeepEqual(got, []string{"b"}) {
+			t.Errorf("LazyPeers() = %v, want [b]", got)
+		}
+	})
+
+	t.Run("lazy peers get announcements on tick", func(t *testing.T) {
+		r := newRecorder("a", "b")
+		r.node.Receive("b", Message[int]{Kind: KindPrune})
+		r.node.Broadcast(1)
+		r.node.Broadcast(2)
+		r.take()
+		r.node.Tick()
+		want := []delivery{{to: "b", msg: Message[int]{Kind: KindIHave, IHave: []Announcement[int]{{1, 1}, {2, 1}}}}}
+		if got := r.take(); !reflect.DeepEqual(got, want) {
+			t.Errorf("sent %v, want %v", got, want)
+		}
+	})
+
+	t.Run("missing value is grafted from its announcers in turn", func(t *testing.T) {
+		r := newRecorder("a", "b")
+		r.node.Receive("a", Message[int]{Kind: KindPrune})
+		r.node.Receive("b", Message[int]{Kind: KindPrune})
+		r.node.Receive("a", Message[int]{Kind: KindIHave, IHave: []Announcement[int]{{1, 4}}})
+		r.node.Receive("b", Message[int]{Kind: KindIHave, IHave: []Announcement[int]{{1, 2}}})
+		r.node.Tick()
+		if got := r.take(); len(got) != 0 {
+			t.Fatalf("sent %v before the graft timeout", got)
+		}
+		r.now = r.now.Add(time.Second)
+		r.node.Tick()
+		want := []delivery{{to: "a", msg: Message[int]{Kind: KindGraft, Value: 1, Round: 4}}}
+		if got := r.take(); !reflect.DeepEqual(got, want) {
+			t.Errorf("sent %v, want %v", got, want)
+		}
+		if got := r.node.EagerPeers(); !reflect.DeepEqual(got, []string{"a"}) {
+			t.Errorf("EagerPeers() = %v, want [a]", got)
+		}
+		r.now = r.now.Add(time.Second / 2)
+		r.node.Tick()
+		want = []delivery{{to: "b", msg: Message[int]{Kind: KindGraft, Value: 1, Round: 2}}}
+		if got := r.take(); !reflect.DeepEqual(got, want) {
+			t.Errorf("sent %v, want %v", got, want)
+		}
+		r.node.Receive("b", gossip(1, 3))
+		r.now = r.now.Add(time.Second)
+		r.node.Tick()
+		if got := r.take(); len(got) != 1 || got[0].msg.Kind != KindGossip {
+			t.Errorf("sent %v after receiving the value, want only its gossip", got)
+		}
+	})
+
+	t.Run("graft resends a received value", func(t *testing.T) {
+		r := newRecorder("a")
+		r.node.Receive("a", Message[int]{Kind: KindPrune})
+		r.node.Broadcast(1)
+		r.node.Receive("a", Message[int]{Kind: KindGraft, Value: 1, Round: 1})
+		want := []delivery{{to: "a", msg: gossip(1, 1)}}
+		if got := r.take(); !reflect.DeepEqual(got, want) {
+			t.Errorf("sent %v, want %v", got, want)
+		}
+		if got := r.node.EagerPeers(); !reflect.DeepEqual(got, []string{"a"}) {
+			t.Errorf("EagerPeers() = %v, want [a]", got)
+		}
+	})
+
+	t.Run("failing peer is dropped until it is reached", func(t *testing.T) {
+		r := newRecorder("a", "b")
+		r.node.config.SuspectAfter = 2
+		if r.node.Failed("a") {
+			t.Fatalf("Failed() = true after one failure, want false")
+		}
+		r.node.Reached("a")
+		if r.node.Failed("a") {
+			t.Fatalf("Failed() = true after failures that were not in a row, want false")
+		}
+		if !r.node.Failed("a") {
+			t.Fatalf("Failed() = false after two failures in a row, want true")
+		}
+		if got := r.node.EagerPeers(); !reflect.DeepEqual(got, []string{"b"}) {
+			t.Errorf("EagerPeers() = %v, want [b]", got)
+		}
+		r.node.Receive("a", Message[int]{Kind: KindIHave})
+		if got := r.node.EagerPeers(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("EagerPeers() after hearing from a = %v, want [a b]", got)
+		}
+	})
+
+	t.Run("graft that times out is a failure", func(t *testing.T) {
+		r := newRecorder("a")
+		r.node.config.SuspectAfter = 1
+		r.node.Receive("a", Message[int]{Kind: KindIHave, IHave: []Announcement[int]{{1, 1}}})
+		r.now = r.now.Add(time.Second)
+		r.node.Tick()
+		r.take()
+		r.now = r.now.Add(time.Second / 2)
+		r.node.Tick()
+		if got := r.node.EagerPeers(); len(got) != 0 {
+			t.Errorf("EagerPeers() = %v after the graft timed out, want none", got)
+		}
+	})
+
+	t.Run("neighbour down drops its announcements", func(t *testing.T) {
+		r := newRecorder("a")
+		r.node.Receive("a", Message[int]{Kind: KindIHave, IHave: []Announcement[int]{{1, 1}}})
+		r.node.NeighbourDown("a")
+		r.now = r.now.Add(time.Second)
+		r.node.Tick()
+		if got := r.take(); len(got) != 0 {
+			t.Errorf("sent %v to a peer that is down", got)
+		}
+		r.node.NeighbourUp("a")
+		if got := r.node.EagerPeers(); !reflect.DeepEqual(got, []string{"a"}) {
+			t.Errorf("EagerPeers() = %v, want [a]", got)
+		}
+	})
+}
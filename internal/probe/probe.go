@@ -0,0 +1,61 @@
+// Package probe measures round-trip times to peers.
+package probe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ping sends a single ping to peer and returns once it is answered.
+type Ping func(ctx context.Context, peer string) error
+
+type Prober struct {
+	ping    Ping
+	samples int
+	timeout time.Duration
+}
+
+// New returns a prober that pings every peer samples times, giving up on a
+// ping after timeout.
+func New(ping Ping, samples int, timeout time.Duration) *Prober {
+	return &Prober{ping: ping, samples: max(samples, 1), timeout: timeout}
+}
+
+// Measure returns the lowest round-trip time to every peer, which is the one
+// least inflated by queueing. Peers are probed concurrently, the pings to a
+// peer one after another. Peers that answer none of the pings are left out.
+func (p *Prober) Measure(peers []string) map[string]time.Duration {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	rtts := make(map[string]time.Duration, len(peers))
+	for _, peer := range peers {
+		wg.Go(func() {
+			rtt, ok := p.measure(peer)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			rtts[peer] = rtt
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return rtts
+}
+
+func (p *Prober) measure(peer string) (time.Duration, bool) {
+	var lowest time.Duration
+	ok := false
+	for range p.samples {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		start := time.Now()
+		err := p.ping(ctx, peer)
+		rtt := time.Since(start)
+		cancel()
+		if err == nil && (!ok || rtt < lowest) {
+			lowest, ok = rtt, true
+		}
+	}
+	return lowest, ok
+}
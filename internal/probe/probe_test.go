@@ -0,0 +1,51 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProber_Measure(t *testing.T) {
+	delays := map[string]time.Duration{"n1": 5 * time.Millisecond, "n2": 30 * time.Millisecond}
+	var pings atomic.Int32
+	ping := func(ctx context.Context, peer string) error {
+		pings.Add(1)
+		delay, ok := delays[peer]
+		if !ok {
+			return errors.New("unreachable")
+		}
+		select {
+		case <-time.After(delay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	rtts := New(ping, 3, time.Second).Measure([]string{"n1", "n2", "n3"})
+	if got := pings.Load(); got != 9 {
+		t.Errorf("sent %d pings, want 9", got)
+	}
+	if _, ok := rtts["n3"]; ok || len(rtts) != 2 {
+		t.Fatalf("Measure() = %v, want n1 and n2 only", rtts)
+	}
+	if rtts["n1"] < delays["n1"] || rtts["n2"] < delays["n2"] {
+		t.Errorf("Measure() = %v, want at least %v", rtts, delays)
+	}
+	if rtts["n1"] >= rtts["n2"] {
+		t.Errorf("Measure() = %v, want n1 faster than n2", rtts)
+	}
+}
+
+func TestProber_Measure_Timeout(t *testing.T) {
+	ping := func(ctx context.Context, peer string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if rtts := New(ping, 2, 5*time.Millisecond).Measure([]string{"n1"}); len(rtts) != 0 {
+		t.Errorf("Measure() = %v, want no peers", rtts)
+	}
+}
@@ -0,0 +1,144 @@
+package topology
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Snapshot returns the peers every node gets from the topology, so any
+// topology can be inspected and exported as a Graph. Nodes the topology does
+// not know get no peers.
+func Snapshot(topology Topology, nodes []string) Graph {
+	g := make(Graph, len(nodes))
+	for _, node := range nodes {
+		g[node] = topology.Peers(node)
+		if g[node] == nil {
+			g[node] = []string{}
+		}
+	}
+	return g
+}
+
+// nodes returns the nodes of g in a stable order: shorter names first, so
+// that n2 comes before n10.
+func (g Graph) nodes() []string {
+	nodes := make([]string, 0, len(g))
+	for node := range g {
+		nodes = append(nodes, node)
+	}
+	slices.SortFunc(nodes, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	})
+	return nodes
+}
+
+// symmetric reports whether every link of g goes both ways.
+func (g Graph) symmetric() bool {
+	for node, peers := range g {
+		for _, peer := range peers {
+			if !slices.Contains(g[peer], node) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// WriteDOT writes g in Graphviz DOT format. A graph whose links all go both
+// ways is written undirected with every link once, any other as a digraph.
+func (g Graph) WriteDOT(w io.Writer, name string) error {
+	kind, arrow := "graph", "--"
+	if !g.symmetric() {
+		kind, arrow = "digraph", "->"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %q {\n", kind, name)
+	nodes := g.nodes()
+	position := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		position[node] = i
+		fmt.Fprintf(&b, "\t%q;\n", node)
+	}
+	for _, node := range nodes {
+		for _, peer := range g[node] {
+			if arrow == "--" && position[peer] < position[node] {
+				continue
+			}
+			fmt.Fprintf(&b, "\t%q %s %q;\n", node, arrow, peer)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Stats summarises how a graph spreads a broadcast.
+type Stats struct {
+	Nodes int `json:"nodes"`
+	// Links counts every link once, in whichever direction.
+	Links      int     `json:"links"`
+	MinFanOut  int     `json:"min_fan_out"`
+	MaxFanOut  int     `json:"max_fan_out"`
+	MeanFanOut float64 `json:"mean_fan_out"`
+	// Depth is the number of hops a broadcast takes to reach every node. The
+	// maximum over all nodes it may start at is the diameter of the graph.
+	MaxDepth  int     `json:"max_depth"`
+	MeanDepth float64 `json:"mean_depth"`
+	// Unreachable counts the pairs of nodes no broadcast gets between.
+	Unreachable int `json:"unreachable"`
+}
+
+func (g Graph) Stats() Stats {
+	stats := Stats{Nodes: len(g)}
+	if len(g) == 0 {
+		return stats
+	}
+	links := map[[2]string]struct{}{}
+	stats.MinFanOut = len(g)
+	totalFanOut, totalDepth := 0, 0
+	for node, peers := range g {
+		stats.MinFanOut = min(stats.MinFanOut, len(peers))
+		stats.MaxFanOut = max(stats.MaxFanOut, len(peers))
+		totalFanOut += len(peers)
+		for _, peer := range peers {
+			links[[2]string{min(node, peer), max(node, peer)}] = struct{}{}
+		}
+
+		depth, reached := g.depth(node)
+		stats.MaxDepth = max(stats.MaxDepth, depth)
+		totalDepth += depth
+		stats.Unreachable += len(g) - reached
+	}
+	stats.Links = len(links)
+	stats.MeanFanOut = float64(totalFanOut) / float64(len(g))
+	stats.MeanDepth = float64(totalDepth) / float64(len(g))
+	return stats
+}
+
+// depth returns the number of hops a broadcast from start takes to reach all
+// nodes it can reach, and how many nodes that is.
+func (g Graph) depth(start string) (int, int) {
+	hops := map[string]int{start: 0}
+	queue := []string{start}
+	depth := 0
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		depth = max(depth, hops[node])
+		for _, peer := range g[node] {
+			if _, known := g[peer]; !known {
+				continue
+			}
+			if _, ok := hops[peer]; !ok {
+				hops[peer] = hops[node] + 1
+				queue = append(queue, peer)
+			}
+		}
+	}
+	return depth, len(hops)
+}
@@ -0,0 +1,130 @@
+package topology
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	tree, _ := NewTree(nodeIDs(4), 2, 1)
+	got := Snapshot(tree, append(nodeIDs(4), "n9"))
+	want := Graph{"n0": {"n1", "n2"}, "n1": {"n3", "n0"}, "n2": {"n0"}, "n3": {"n1"}, "n9": {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot() = %v, want %v", got, want)
+	}
+}
+
+func TestGraph_WriteDOT(t *testing.T) {
+	tree, _ := NewTree(nodeIDs(4), 2, 1)
+	tests := []struct {
+		name  string
+		graph Graph
+		want  string
+	}{
+		{
+			name:  "tree",
+			graph: Snapshot(tree, nodeIDs(4)),
+			want: `graph "tree" {
+	"n0";
+	"n1";
+	"n2";
+	"n3";
+	"n0" -- "n1";
+	"n0" -- "n2";
+	"n1" -- "n3";
+}
+`,
+		},
+		{
+			name:  "one-way links",
+			graph: Graph{"n0": {"n1"}, "n1": {}},
+			want: `digraph "one-way links" {
+	"n0";
+	"n1";
+	"n0" -> "n1";
+}
+`,
+		},
+		{
+			name:  "numeric order",
+			graph: Graph{"n10": {"n2"}, "n2": {"n10"}},
+			want: `graph "numeric order" {
+	"n2";
+	"n10";
+	"n2" -- "n10";
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := tt.graph.WriteDOT(&b, tt.name); err != nil {
+				t.Fatalf("WriteDOT() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("WriteDOT() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraph_JSON(t *testing.T) {
+	g, _ := NewHypercube(nodeIDs(8))
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got Graph
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, g) {
+		t.Errorf("round trip = %v, want %v", got, g)
+	}
+}
+
+func TestGraph_Stats(t *testing.T) {
+	star, _ := NewStar(nodeIDs(5))
+	ring, _ := NewRing(nodeIDs(6))
+	tests := []struct {
+		name  string
+		graph Graph
+		want  Stats
+	}{
+		{
+			name:  "star",
+			graph: star,
+			want:  Stats{Nodes: 5, Links: 4, MinFanOut: 1, MaxFanOut: 4, MeanFanOut: 1.6, MaxDepth: 2, MeanDepth: 1.8},
+		},
+		{
+			name:  "ring",
+			graph: ring,
+			want:  Stats{Nodes: 6, Links: 6, MinFanOut: 2, MaxFanOut: 2, MeanFanOut: 2, MaxDepth: 3, MeanDepth: 3},
+		},
+		{
+			name:  "disconnected",
+			graph: Graph{"a": {"b"}, "b": {"a"}, "c": {}},
+			want:  Stats{Nodes: 3, Links: 1, MinFanOut: 0, MaxFanOut: 1, MeanFanOut: 2.0 / 3, MaxDepth: 1, MeanDepth: 2.0 / 3, Unreachable: 4},
+		},
+		{
+			name:  "links to unknown nodes",
+			graph: Graph{"a": {"x"}},
+			want:  Stats{Nodes: 1, Links: 1, MinFanOut: 1, MaxFanOut: 1, MeanFanOut: 1},
+		},
+		{
+			name:  "empty",
+			graph: Graph{},
+			want:  Stats{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.graph.Stats(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
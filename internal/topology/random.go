@@ -0,0 +1,100 @@
+package topology
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// maxAttempts bounds how many random graphs are drawn looking for a connected
+// one.
+const maxAttempts = 100
+
+// NewRandomRegular returns a random connected graph in which every node has
+// degree peers. It starts from a ring lattice and shuffles it with degree
+// preserving swaps of link ends, which keeps the graph simple.
+func NewRandomRegular(nodes []string, degree int, seed uint64) (Graph, error) {
+	if _, err := newGraph(nodes); err != nil {
+		return nil, err
+	}
+	lattice, err := ringLattice(len(nodes), degree)
+	if err != nil {
+		return nil, err
+	}
+	r := rand.New(rand.NewPCG(seed, seed))
+	for range maxAttempts {
+		edges, links := shuffleable(lattice)
+		for range 10 * len(edges) {
+			i, j := r.IntN(len(edges)), r.IntN(len(edges))
+			a, b, c, d := edges[i].a, edges[i].b, edges[j].a, edges[j].b
+			if r.IntN(2) == 0 {
+				c, d = d, c
+			}
+			ad, cb := edge{a, d}.undirected(), edge{c, b}.undirected()
+			if a == d || c == b || links[ad] || links[cb] {
+				continue
+			}
+			delete(links, edges[i].undirected())
+			delete(links, edges[j].undirected())
+			links[ad], links[cb] = true, true
+			edges[i], edges[j] = edge{a, d}, edge{c, b}
+		}
+		if g := graphOf(nodes, edges); g.connected() {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no connected %d-regular graph over %d nodes found", ErrDisconnected, degree, len(nodes))
+}
+
+// NewSmallWorld returns a Watts-Strogatz graph: a ring lattice of the given
+// degree in which every link is moved to a random node with probability
+// rewire. The lattice keeps neighbourhoods clustered and the few random links
+// shorten the paths across the ring.
+func NewSmallWorld(nodes []string, degree int, rewire float64, seed uint64) (Graph, error) {
+	if _, err := newGraph(nodes); err != nil {
+		return nil, err
+	}
+	if rewire < 0 || rewire > 1 {
+		return nil, fmt.Errorf("%w: rewire probability %v", ErrInvalidOption, rewire)
+	}
+	lattice, err := ringLattice(len(nodes), degree)
+	if err != nil {
+		return nil, err
+	}
+	r := rand.New(rand.NewPCG(seed, seed))
+	for range maxAttempts {
+		edges, links := shuffleable(lattice)
+		for i, e := range edges {
+			if r.Float64() >= rewire {
+				continue
+			}
+			moved := edge{e.a, r.IntN(len(nodes))}
+			if moved.a == moved.b || links[moved.undirected()] {
+				continue
+			}
+			delete(links, e.undirected())
+			links[moved.undirected()] = true
+			edges[i] = moved
+		}
+		if g := graphOf(nodes, edges); g.connected() {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no connected small-world graph over %d nodes found", ErrDisconnected, len(nodes))
+}
+
+// shuffleable returns a copy of edges and the set of its links.
+func shuffleable(edges []edge) ([]edge, map[edge]bool) {
+	links := make(map[edge]bool, len(edges))
+	for _, e := range edges {
+		links[e.undirected()] = true
+	}
+	return append([]edge{}, edges...), links
+}
+
+func graphOf(nodes []string, edges []edge) Graph {
+	g, _ := newGraph(nodes)
+	for _, e := range edges {
+		g.link(nodes[e.a], nodes[e.b])
+	}
+	return g
+}
@@ -0,0 +1,192 @@
+package topology
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"gossip-glomers/internal/tree"
+)
+
+// Tree is a forest of k-ary trees of package tree with disjoint inner nodes,
+// see tree.NewForest: a node's peers are its children and parents in every
+// tree. A single tree costs the fewest messages per broadcast; every further
+// tree adds another path to each node that avoids the relays of the others.
+type Tree struct {
+	Forest []*tree.Tree
+}
+
+func NewTree(nodes []string, branching, trees int) (*Tree, error) {
+	forest, err := tree.NewForest(nodes, branching, trees)
+	if err != nil {
+		return nil, err
+	}
+	return &Tree{Forest: forest}, nil
+}
+
+func (t *Tree) Peers(node string) []string {
+	peers, err := t.LivePeers(node, nil)
+	if err != nil {
+		return nil
+	}
+	return peers
+}
+
+// LivePeers returns the peers of node in the trees repaired around the
+// suspected nodes, see tree.Tree.Repair.
+func (t *Tree) LivePeers(node string, suspected []string) ([]string, error) {
+	peers := []string{}
+	for _, tr := range t.Forest {
+		repaired, err := tr.Repair(suspected)
+		if err != nil {
+			return nil, err
+		}
+		neighbours, err := repaired.Neighbours(node)
+		if err != nil {
+			return nil, err
+		}
+		for _, peer := range neighbours {
+			if !slices.Contains(peers, peer) {
+				peers = append(peers, peer)
+			}
+		}
+	}
+	return peers, nil
+}
+
+// LatencyTree is the spanning tree of package tree that keeps slow links out,
+// see tree.NewLatencyTree. It is rebuilt over the live nodes on repair.
+type LatencyTree struct {
+	*tree.LatencyTree
+	nodes       []string
+	maxChildren int
+	latencies   tree.Latencies
+}
+
+func NewLatencyTree(nodes []string, maxChildren int, latencies tree.Latencies) (*LatencyTree, error) {
+	t, err := tree.NewLatencyTree(nodes, maxChildren, latencies)
+	if err != nil {
+		return nil, err
+	}
+	return &LatencyTree{LatencyTree: t, nodes: nodes, maxChildren: maxChildren, latencies: latencies}, nil
+}
+
+func (t *LatencyTree) Peers(node string) []string {
+	peers, err := t.Neighbours(node)
+	if err != nil {
+		return nil
+	}
+	return peers
+}
+
+func (t *LatencyTree) LivePeers(node string, suspected []string) ([]string, error) {
+	if len(suspected) == 0 {
+		return t.Neighbours(node)
+	}
+	live := slices.DeleteFunc(slices.Clone(t.nodes), func(n string) bool {
+		return slices.Contains(suspected, n)
+	})
+	repaired, err := tree.NewLatencyTree(live, t.maxChildren, t.latencies)
+	if err != nil {
+		return nil, err
+	}
+	return repaired.Neighbours(node)
+}
+
+// NewRing links every node to the nodes before and after it, wrapping
+// around. A message crosses up to half the ring, but a node has 2 peers.
+func NewRing(nodes []string) (Graph, error) {
+	g, err := newGraph(nodes)
+	if err != nil {
+		return nil, err
+	}
+	for i, node := range nodes {
+		g.link(node, nodes[(i+1)%len(nodes)])
+	}
+	return g, nil
+}
+
+// NewHypercube links nodes whose positions differ in a single bit. With a
+// node count that is not a power of two, links to missing positions are
+// dropped; clearing the highest bit of a position always leads to a smaller
+// one, so the graph stays connected.
+func NewHypercube(nodes []string) (Graph, error) {
+	g, err := newGraph(nodes)
+	if err != nil {
+		return nil, err
+	}
+	for i, node := range nodes {
+		for bit := 1; bit < len(nodes); bit <<= 1 {
+			if j := i ^ bit; j < len(nodes) {
+				g.link(node, nodes[j])
+			}
+		}
+	}
+	return g, nil
+}
+
+// NewGrid lays nodes out row by row in a square grid, the last row possibly
+// short, and links every node to its horizontal and vertical neighbours.
+func NewGrid(nodes []string) (Graph, error) {
+	g, err := newGraph(nodes)
+	if err != nil {
+		return nil, err
+	}
+	cols := int(math.Ceil(math.Sqrt(float64(len(nodes)))))
+	for i, node := range nodes {
+		if right := i + 1; right%cols != 0 && right < len(nodes) {
+			g.link(node, nodes[right])
+		}
+		if below := i + cols; below < len(nodes) {
+			g.link(node, nodes[below])
+		}
+	}
+	return g, nil
+}
+
+// NewStar links every node to the first one, which relays everything.
+func NewStar(nodes []string) (Graph, error) {
+	g, err := newGraph(nodes)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes[1:] {
+		g.link(nodes[0], node)
+	}
+	return g, nil
+}
+
+type edge struct {
+	a, b int
+}
+
+// undirected returns e with its ends in order, so both directions of a link
+// compare equal.
+func (e edge) undirected() edge {
+	if e.a > e.b {
+		return edge{e.b, e.a}
+	}
+	return e
+}
+
+// ringLattice returns the links of n nodes in a ring where every node is
+// linked to the degree/2 nodes following it. An odd degree also links it to
+// the node opposite, which needs an even node count.
+func ringLattice(n, degree int) ([]edge, error) {
+	if degree < 2 || degree >= n {
+		return nil, fmt.Errorf("%w: degree %d for %d nodes", ErrInvalidOption, degree, n)
+	}
+	if degree%2 == 1 && n%2 == 1 {
+		return nil, fmt.Errorf("%w: odd degree %d for an odd node count %d", ErrInvalidOption, degree, n)
+	}
+	var edges []edge
+	for i := range n {
+		for d := 1; d <= degree/2; d++ {
+			edges = append(edges, edge{i, (i + d) % n})
+		}
+		if degree%2 == 1 && i < n/2 {
+			edges = append(edges, edge{i, i + n/2})
+		}
+	}
+	return edges, nil
+}
@@ -0,0 +1,178 @@
+// Package topology builds the overlays broadcast nodes gossip over. Every
+// shape is computed from the node list alone, so all nodes that are given the
+// same list and options agree on it without talking to each other.
+package topology
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gossip-glomers/internal/tree"
+)
+
+var (
+	ErrNoNodes         = errors.New("topology has no nodes")
+	ErrDuplicateNode   = errors.New("duplicate node")
+	ErrInvalidOption   = errors.New("invalid topology option")
+	ErrUnknownTopology = errors.New("unknown topology")
+	ErrDisconnected    = errors.New("topology is not connected")
+	ErrNeedsLatencies  = errors.New("topology needs measured latencies")
+)
+
+// Topology names accepted by New.
+const (
+	NameTree          = "tree"
+	NameLatencyTree   = "latency-tree"
+	NameRing          = "ring"
+	NameHypercube     = "hypercube"
+	NameGrid          = "grid"
+	NameStar          = "star"
+	NameRandomRegular = "random-regular"
+	NameSmallWorld    = "small-world"
+	NameMaelstrom     = "maelstrom"
+)
+
+// Names lists the topologies New builds.
+var Names = []string{NameTree, NameLatencyTree, NameRing, NameHypercube, NameGrid, NameStar, NameRandomRegular, NameSmallWorld, NameMaelstrom}
+
+// Validate checks that name is a topology New builds without measured
+// latencies. A latency tree without them is a plain tree, so it is rejected.
+func Validate(name string) error {
+	if name == NameLatencyTree {
+		return fmt.Errorf("%w: %q", ErrNeedsLatencies, name)
+	}
+	return ValidateWithLatencies(name)
+}
+
+// ValidateWithLatencies checks that name is a topology New builds, for nodes
+// that measure the latencies a latency tree needs.
+func ValidateWithLatencies(name string) error {
+	if !slices.Contains(Names, name) {
+		return fmt.Errorf("%w %q", ErrUnknownTopology, name)
+	}
+	return nil
+}
+
+// Topology tells a node which peers to gossip with.
+type Topology interface {
+	// Peers returns the peers of node, or nil if node is not in the
+	// topology. The slice is the caller's to modify.
+	Peers(node string) []string
+}
+
+// Repairable is a topology that reroutes around failed nodes itself rather
+// than just losing the links to them.
+type Repairable interface {
+	Topology
+	// LivePeers returns the peers of node in the topology without the
+	// suspected nodes.
+	LivePeers(node string, suspected []string) ([]string, error)
+}
+
+var (
+	_ Repairable = (*Tree)(nil)
+	_ Repairable = (*LatencyTree)(nil)
+)
+
+// Options tune the shapes that take parameters. Zero fields take defaults.
+type Options struct {
+	// Branching is the number of children of inner tree nodes. Defaults to 2.
+	Branching int
+	// Latencies are the round-trip times a latency tree keeps slow links out
+	// with. Nodes must agree on them.
+	Latencies tree.Latencies
+	// Trees is the number of trees with disjoint inner nodes a tree topology
+	// broadcasts over. Defaults to 1.
+	Trees int
+	// Degree is the number of peers of every node in random-regular and
+	// small-world graphs. Defaults to 4.
+	Degree int
+	// Rewire is the probability that a small-world edge is rewired to a
+	// random node. Defaults to 0.2.
+	Rewire float64
+	// Seed drives the random shapes. Nodes must agree on it.
+	Seed uint64
+	// Given is the topology Maelstrom sends in its topology message.
+	Given map[string][]string
+}
+
+// New builds the topology called name over nodes.
+func New(name string, nodes []string, opts Options) (Topology, error) {
+	var topology Topology
+	var err error
+	switch name {
+	case NameTree:
+		topology, err = NewTree(nodes, cmp.Or(opts.Branching, 2), cmp.Or(opts.Trees, 1))
+	case NameLatencyTree:
+		topology, err = NewLatencyTree(nodes, cmp.Or(opts.Branching, 2), opts.Latencies)
+	case NameRing:
+		topology, err = NewRing(nodes)
+	case NameHypercube:
+		topology, err = NewHypercube(nodes)
+	case NameGrid:
+		topology, err = NewGrid(nodes)
+	case NameStar:
+		topology, err = NewStar(nodes)
+	case NameRandomRegular:
+		topology, err = NewRandomRegular(nodes, cmp.Or(opts.Degree, 4), opts.Seed)
+	case NameSmallWorld:
+		topology, err = NewSmallWorld(nodes, cmp.Or(opts.Degree, 4), cmp.Or(opts.Rewire, 0.2), opts.Seed)
+	case NameMaelstrom:
+		if opts.Given == nil {
+			return nil, fmt.Errorf("%w: no topology given", ErrInvalidOption)
+		}
+		topology = Graph(opts.Given)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownTopology, name)
+	}
+	// Constructors return typed nils on error, which must not leak out as
+	// non-nil interfaces
+	if err != nil {
+		return nil, err
+	}
+	return topology, nil
+}
+
+// Graph is a topology given by the peers of every node.
+type Graph map[string][]string
+
+func (g Graph) Peers(node string) []string {
+	return slices.Clone(g[node])
+}
+
+// newGraph returns a graph over nodes without any links, after checking that
+// nodes is non-empty and free of duplicates.
+func newGraph(nodes []string) (Graph, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	g := make(Graph, len(nodes))
+	for _, node := range nodes {
+		if _, ok := g[node]; ok {
+			return nil, fmt.Errorf("%w %q", ErrDuplicateNode, node)
+		}
+		g[node] = []string{}
+	}
+	return g, nil
+}
+
+// link connects a and b both ways. Self links and repeated links are
+// ignored.
+func (g Graph) link(a, b string) {
+	if a == b || slices.Contains(g[a], b) {
+		return
+	}
+	g[a] = append(g[a], b)
+	g[b] = append(g[b], a)
+}
+
+// connected reports whether every node of g is reachable from every other.
+func (g Graph) connected() bool {
+	for start := range g {
+		_, reached := g.depth(start)
+		return reached == len(g)
+	}
+	return true
+}
@@ -0,0 +1,259 @@
+package topology
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"gossip-glomers/internal/tree"
+)
+
+func nodeIDs(n int) []string {
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = fmt.Sprint("n", i)
+	}
+	return nodes
+}
+
+// checkShape fails unless every node of topology has peers within the
+// topology, links are symmetric and free of self links, and all nodes are
+// reachable from the first.
+func checkShape(t *testing.T, topology Topology, nodes []string) {
+	t.Helper()
+	seen := map[string]bool{nodes[0]: true}
+	queue := []string{nodes[0]}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, peer := range topology.Peers(node) {
+			if peer == node {
+				t.Fatalf("%s is its own peer", node)
+			}
+			if !slices.Contains(nodes, peer) {
+				t.Fatalf("peer %s of %s is not a node", peer, node)
+			}
+			if !slices.Contains(topology.Peers(peer), node) {
+				t.Fatalf("%s peers with %s, but not the other way around", node, peer)
+			}
+			if !seen[peer] {
+				seen[peer] = true
+				queue = append(queue, peer)
+			}
+		}
+	}
+	if len(seen) != len(nodes) {
+		t.Fatalf("reached %d of %d nodes", len(seen), len(nodes))
+	}
+}
+
+func TestNew_Shapes(t *testing.T) {
+	for _, name := range []string{NameTree, NameLatencyTree, NameRing, NameHypercube, NameGrid, NameStar, NameRandomRegular, NameSmallWorld} {
+		for _, n := range []int{5, 6, 16, 25, 50} {
+			t.Run(fmt.Sprintf("%s/%d", name, n), func(t *testing.T) {
+				nodes := nodeIDs(n)
+				topology, err := New(name, nodes, Options{Seed: 7, Trees: 2})
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				checkShape(t, topology, nodes)
+			})
+		}
+	}
+}
+
+func TestNew_Peers(t *testing.T) {
+	tests := []struct {
+		name  string
+		shape string
+		nodes int
+		opts  Options
+		node  string
+		want  []string
+	}{
+		{name: "tree root", shape: NameTree, nodes: 7, node: "n0", want: []string{"n1", "n2"}},
+		{name: "tree inner node", shape: NameTree, nodes: 7, opts: Options{Branching: 3}, node: "n1", want: []string{"n4", "n5", "n6", "n0"}},
+		{name: "forest root", shape: NameTree, nodes: 7, opts: Options{Trees: 2}, node: "n0", want: []string{"n1", "n2", "n4"}},
+		{name: "forest inner node", shape: NameTree, nodes: 7, opts: Options{Trees: 2}, node: "n4", want: []string{"n1", "n6", "n0", "n3"}},
+		{name: "latency tree without latencies", shape: NameLatencyTree, nodes: 7, node: "n1", want: []string{"n3", "n4", "n0"}},
+		{
+			name:  "latency tree",
+			shape: NameLatencyTree,
+			nodes: 3,
+			opts:  Options{Latencies: tree.Latencies{"n0": {"n1": time.Second, "n2": time.Millisecond}, "n2": {"n1": time.Millisecond}}},
+			node:  "n2",
+			want:  []string{"n0", "n1"},
+		},
+		{name: "ring wraps around", shape: NameRing, nodes: 5, node: "n0", want: []string{"n1", "n4"}},
+		{name: "ring of two", shape: NameRing, nodes: 2, node: "n0", want: []string{"n1"}},
+		{name: "ring of one", shape: NameRing, nodes: 1, node: "n0", want: []string{}},
+		{name: "hypercube", shape: NameHypercube, nodes: 8, node: "n5", want: []string{"n4", "n7", "n1"}},
+		{name: "incomplete hypercube", shape: NameHypercube, nodes: 6, node: "n5", want: []string{"n4", "n1"}},
+		{name: "grid corner", shape: NameGrid, nodes: 9, node: "n0", want: []string{"n1", "n3"}},
+		{name: "grid centre", shape: NameGrid, nodes: 9, node: "n4", want: []string{"n1", "n3", "n5", "n7"}},
+		{name: "grid short last row", shape: NameGrid, nodes: 7, node: "n5", want: []string{"n2", "n4"}},
+		{name: "star hub", shape: NameStar, nodes: 4, node: "n0", want: []string{"n1", "n2", "n3"}},
+		{name: "star leaf", shape: NameStar, nodes: 4, node: "n2", want: []string{"n0"}},
+		{
+			name:  "maelstrom",
+			shape: NameMaelstrom,
+			opts:  Options{Given: map[string][]string{"n0": {"n1"}, "n1": {"n0"}}},
+			node:  "n0",
+			want:  []string{"n1"},
+		},
+		{name: "unknown node", shape: NameRing, nodes: 3, node: "n9", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topology, err := New(tt.shape, nodeIDs(tt.nodes), tt.opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got := topology.Peers(tt.node)
+			if tt.want != nil {
+				slices.Sort(got)
+				slices.Sort(tt.want)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Peers(%s) = %v, want %v", tt.node, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		shape   string
+		nodes   []string
+		opts    Options
+		wantErr error
+	}{
+		{name: "too many trees", shape: NameTree, nodes: nodeIDs(7), opts: Options{Trees: 3}, wantErr: tree.ErrForestSize},
+		{name: "unknown shape", shape: "moebius", nodes: nodeIDs(4), wantErr: ErrUnknownTopology},
+		{name: "no nodes", shape: NameRing, wantErr: ErrNoNodes},
+		{name: "duplicate node", shape: NameGrid, nodes: []string{"n0", "n1", "n0"}, wantErr: ErrDuplicateNode},
+		{name: "degree too high", shape: NameRandomRegular, nodes: nodeIDs(4), opts: Options{Degree: 4}, wantErr: ErrInvalidOption},
+		{name: "odd degree and node count", shape: NameRandomRegular, nodes: nodeIDs(5), opts: Options{Degree: 3}, wantErr: ErrInvalidOption},
+		{name: "rewire out of range", shape: NameSmallWorld, nodes: nodeIDs(10), opts: Options{Rewire: 2}, wantErr: ErrInvalidOption},
+		{name: "maelstrom without topology", shape: NameMaelstrom, nodes: nodeIDs(3), wantErr: ErrInvalidOption},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topology, err := New(tt.shape, tt.nodes, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+			if topology != nil {
+				t.Errorf("New() = %v, want nil", topology)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name            string
+		shape           string
+		wantErr         error
+		wantErrMeasured error
+	}{
+		{name: "tree", shape: NameTree},
+		{name: "maelstrom", shape: NameMaelstrom},
+		{name: "latency tree", shape: NameLatencyTree, wantErr: ErrNeedsLatencies},
+		{name: "unknown", shape: "moebius", wantErr: ErrUnknownTopology, wantErrMeasured: ErrUnknownTopology},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.shape); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := ValidateWithLatencies(tt.shape); !errors.Is(err, tt.wantErrMeasured) {
+				t.Errorf("ValidateWithLatencies() error = %v, want %v", err, tt.wantErrMeasured)
+			}
+		})
+	}
+}
+
+func TestNewRandomRegular(t *testing.T) {
+	for _, degree := range []int{2, 3, 4, 7} {
+		nodes := nodeIDs(30)
+		g, err := NewRandomRegular(nodes, degree, 1)
+		if err != nil {
+			t.Fatalf("NewRandomRegular(degree %d) error = %v", degree, err)
+		}
+		for _, node := range nodes {
+			if got := len(g.Peers(node)); got != degree {
+				t.Errorf("degree %d: %s has %d peers", degree, node, got)
+			}
+		}
+		lattice, _ := ringLattice(len(nodes), degree)
+		if reflect.DeepEqual(g, graphOf(nodes, lattice)) {
+			t.Errorf("degree %d: graph is the unshuffled lattice", degree)
+		}
+	}
+}
+
+// Nodes build their overlay independently, so they must all get the same one
+func TestRandomShapes_Deterministic(t *testing.T) {
+	for _, name := range []string{NameRandomRegular, NameSmallWorld} {
+		nodes := nodeIDs(25)
+		a, _ := New(name, nodes, Options{Seed: 3})
+		b, _ := New(name, nodes, Options{Seed: 3})
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("%s with the same seed differs", name)
+		}
+		c, _ := New(name, nodes, Options{Seed: 4})
+		if reflect.DeepEqual(a, c) {
+			t.Errorf("%s with another seed is the same", name)
+		}
+	}
+}
+
+func TestNewSmallWorld_Rewire(t *testing.T) {
+	nodes := nodeIDs(20)
+	lattice, _ := NewSmallWorld(nodes, 4, 0, 1)
+	want, _ := ringLattice(len(nodes), 4)
+	if !reflect.DeepEqual(lattice, graphOf(nodes, want)) {
+		t.Errorf("NewSmallWorld() without rewiring is not the ring lattice")
+	}
+	rewired, _ := NewSmallWorld(nodes, 4, 0.5, 1)
+	if reflect.DeepEqual(rewired, lattice) {
+		t.Errorf("NewSmallWorld() with rewiring is the ring lattice")
+	}
+}
+
+func TestRepairable_LivePeers(t *testing.T) {
+	nodes := nodeIDs(7)
+	tests := []struct {
+		name      string
+		shape     string
+		suspected []string
+		node      string
+		want      []string
+	}{
+		{name: "tree", shape: NameTree, node: "n1", want: []string{"n0", "n3", "n4"}},
+		{name: "tree adopts around failed node", shape: NameTree, suspected: []string{"n1"}, node: "n3", want: []string{"n0"}},
+		{name: "latency tree", shape: NameLatencyTree, node: "n1", want: []string{"n0", "n3", "n4"}},
+		{name: "latency tree rebuilt without failed node", shape: NameLatencyTree, suspected: []string{"n1"}, node: "n0", want: []string{"n2", "n3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topology, err := New(tt.shape, nodes, Options{})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got, err := topology.(Repairable).LivePeers(tt.node, tt.suspected)
+			if err != nil {
+				t.Fatalf("LivePeers() error = %v", err)
+			}
+			slices.Sort(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LivePeers(%s) = %v, want %v", tt.node, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrForestSize = errors.New("invalid number of trees")
+
+// NewForest returns k trees over nodes whose inner nodes are disjoint: every
+// node relays in at most one tree and is a leaf in all others, so a failed
+// node cuts off a subtree in one tree only and the others still reach every
+// live node. Tree i lays nodes out rotated by i times the number of inner
+// nodes, so the k sets of inner nodes must fit in nodes: k is at most about
+// branching.
+func NewForest(nodes []string, branching, k int) ([]*Tree, error) {
+	first, err := NewTree(nodes, branching)
+	if err != nil {
+		return nil, err
+	}
+	inner := first.firstLeaf()
+	if k < 1 || k*inner > len(nodes) {
+		return nil, fmt.Errorf("%w: %d trees with %d inner nodes each over %d nodes", ErrForestSize, k, inner, len(nodes))
+	}
+	forest := []*Tree{first}
+	for i := 1; i < k; i++ {
+		offset := i * inner
+		forest = append(forest, MustNewTree(append(slices.Clone(nodes[offset:]), nodes[:offset]...), branching))
+	}
+	return forest, nil
+}
@@ -0,0 +1,89 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestNewForest(t *testing.T) {
+	tests := []struct {
+		nodes     int
+		branching int
+		k         int
+	}{
+		{nodes: 1, branching: 2, k: 3},
+		{nodes: 10, branching: 3, k: 1},
+		{nodes: 10, branching: 3, k: 3},
+		{nodes: 25, branching: 5, k: 5},
+		{nodes: 25, branching: 4, k: 4},
+		{nodes: 100, branching: 8, k: 7},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d nodes, branching %d, %d trees", tt.nodes, tt.branching, tt.k), func(t *testing.T) {
+			forest, err := NewForest(nodeIDs(tt.nodes), tt.branching, tt.k)
+			if err != nil {
+				t.Fatalf("NewForest() error = %v", err)
+			}
+			if len(forest) != tt.k {
+				t.Fatalf("NewForest() returned %d trees, want %d", len(forest), tt.k)
+			}
+			relays := map[string]int{}
+			for i, tree := range forest {
+				subtree, _ := tree.Subtree(tree.Root())
+				if !slices.Equal(slices.Sorted(slices.Values(subtree)), slices.Sorted(slices.Values(nodeIDs(tt.nodes)))) {
+					t.Errorf("tree %d spans %v", i, subtree)
+				}
+				for _, node := range tree.Inner() {
+					relays[node]++
+					if relays[node] > 1 {
+						t.Errorf("%s is an inner node of several trees", node)
+					}
+				}
+			}
+		})
+	}
+}
+
+// With one failed node, every live node is still reached from the root of
+// all trees but the one the failed node relays in
+func TestNewForest_FailureIndependent(t *testing.T) {
+	nodes := nodeIDs(25)
+	forest, err := NewForest(nodes, 5, 3)
+	if err != nil {
+		t.Fatalf("NewForest() error = %v", err)
+	}
+	for _, failed := range nodes {
+		cut := 0
+		for _, tree := range forest {
+			if subtree, _ := tree.Subtree(failed); len(subtree) > 1 || tree.IsRoot(failed) {
+				cut++
+			}
+		}
+		if cut > 1 {
+			t.Errorf("failure of %s cuts off nodes in %d trees", failed, cut)
+		}
+	}
+}
+
+func TestNewForest_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		nodes     []string
+		branching int
+		k         int
+		wantErr   error
+	}{
+		{name: "no trees", nodes: nodeIDs(10), branching: 3, k: 0, wantErr: ErrForestSize},
+		{name: "too many trees", nodes: nodeIDs(10), branching: 3, k: 4, wantErr: ErrForestSize},
+		{name: "invalid tree", nodes: nil, branching: 3, k: 1, wantErr: ErrNoNodes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewForest(tt.nodes, tt.branching, tt.k); !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewForest() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
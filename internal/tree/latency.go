@@ -0,0 +1,114 @@
+package tree
+
+import (
+	"fmt"
+	"time"
+)
+
+// Unmeasured is the round-trip time assumed between nodes that have not
+// measured each other, so their links are used only when nothing else is
+// left.
+const Unmeasured = time.Hour
+
+// Latencies holds the round-trip times every node measured to its peers.
+type Latencies map[string]map[string]time.Duration
+
+// RTT returns the round-trip time between a and b: the mean of what both
+// measured, so that every node derives the same value from the same rows.
+func (l Latencies) RTT(a, b string) time.Duration {
+	ab, okAB := l[a][b]
+	ba, okBA := l[b][a]
+	switch {
+	case okAB && okBA:
+		return (ab + ba) / 2
+	case okAB:
+		return ab
+	case okBA:
+		return ba
+	default:
+		return Unmeasured
+	}
+}
+
+// LatencyTree is a spanning tree that keeps slow links out: it is grown with
+// Prim's algorithm from the node closest to all others, always adding the
+// fastest link from a node in the tree that still has room for a child.
+// Without measurements it is the k-ary tree over nodes in the given order.
+type LatencyTree struct {
+	linked
+}
+
+// NewLatencyTree returns the latency tree over nodes in which no node has
+// more than maxChildren children, or any number if maxChildren is 0. Ties
+// are broken by the order of nodes, so every node computes the same tree from
+// the same latencies.
+func NewLatencyTree(nodes []string, maxChildren int, latencies Latencies) (*LatencyTree, error) {
+	if _, err := indexNodes(nodes); err != nil {
+		return nil, err
+	}
+	if maxChildren < 0 {
+		return nil, fmt.Errorf("%w, got %d", ErrInvalidBranches, maxChildren)
+	}
+	n := len(nodes)
+	capacity := maxChildren
+	if capacity == 0 {
+		capacity = n
+	}
+	rtt := make([][]time.Duration, n)
+	root, rootTotal := 0, time.Duration(-1)
+	for i := range nodes {
+		rtt[i] = make([]time.Duration, n)
+		var total time.Duration
+		for j := range nodes {
+			if i != j {
+				rtt[i][j] = latencies.RTT(nodes[i], nodes[j])
+				total += rtt[i][j]
+			}
+		}
+		if rootTotal < 0 || total < rootTotal {
+			root, rootTotal = i, total
+		}
+	}
+
+	t := &LatencyTree{linked: newLinked(n)}
+	t.place(nodes[root], nodes[root])
+	placed := []int{root}
+	inTree := make([]bool, n)
+	inTree[root] = true
+	children := make([]int, n)
+	// best is the parent in the tree with room that is closest to each node
+	// outside it
+	best := make([]int, n)
+	for i := range best {
+		best[i] = root
+	}
+	for range n - 1 {
+		next := -1
+		for i := range nodes {
+			if !inTree[i] && (next == -1 || rtt[i][best[i]] < rtt[next][best[next]]) {
+				next = i
+			}
+		}
+		parent := best[next]
+		t.place(nodes[next], nodes[parent])
+		placed = append(placed, next)
+		inTree[next] = true
+		children[parent]++
+
+		for i := range nodes {
+			switch {
+			case inTree[i]:
+			case children[best[i]] == capacity:
+				best[i] = -1
+				for _, candidate := range placed {
+					if children[candidate] < capacity && (best[i] == -1 || rtt[i][candidate] < rtt[i][best[i]]) {
+						best[i] = candidate
+					}
+				}
+			case rtt[i][next] < rtt[i][best[i]]:
+				best[i] = next
+			}
+		}
+	}
+	return t, nil
+}
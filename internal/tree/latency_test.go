@@ -0,0 +1,214 @@
+package tree
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLatencies_RTT(t *testing.T) {
+	latencies := Latencies{
+		"n0": {"n1": 10 * time.Millisecond, "n2": 30 * time.Millisecond},
+		"n1": {"n0": 20 * time.Millisecond},
+	}
+	tests := []struct {
+		name string
+		a, b string
+		want time.Duration
+	}{
+		{"measured both ways", "n0", "n1", 15 * time.Millisecond},
+		{"measured both ways, reversed", "n1", "n0", 15 * time.Millisecond},
+		{"measured one way", "n2", "n0", 30 * time.Millisecond},
+		{"unmeasured", "n1", "n2", Unmeasured},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := latencies.RTT(tt.a, tt.b); got != tt.want {
+				t.Errorf("RTT(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// symmetric returns latencies where the given links take their round-trip
+// time both ways and all others take slow.
+func symmetric(nodes []string, slow time.Duration, links map[[2]string]time.Duration) Latencies {
+	latencies := Latencies{}
+	for _, a := range nodes {
+		latencies[a] = map[string]time.Duration{}
+		for _, b := range nodes {
+			if a != b {
+				latencies[a][b] = slow
+			}
+		}
+	}
+	for link, rtt := range links {
+		latencies[link[0]][link[1]] = rtt
+		latencies[link[1]][link[0]] = rtt
+	}
+	return latencies
+}
+
+func TestNewLatencyTree(t *testing.T) {
+	nodes := nodeIDs(4)
+	fast := symmetric(nodes, 100*time.Millisecond, map[[2]string]time.Duration{
+		{"n0", "n3"}: 10 * time.Millisecond,
+		{"n3", "n1"}: 10 * time.Millisecond,
+		{"n1", "n2"}: 10 * time.Millisecond,
+	})
+	tests := []struct {
+		name           string
+		maxChildren    int
+		wantRoot       string
+		wantNeighbours map[string][]string
+	}{
+		{
+			name:     "unbounded follows the fast links",
+			wantRoot: "n1",
+			wantNeighbours: map[string][]string{
+				"n1": {"n2", "n3"},
+				"n3": {"n0", "n1"},
+				"n0": {"n3"},
+			},
+		},
+		{
+			name:        "one child each makes a chain",
+			maxChildren: 1,
+			wantRoot:    "n1",
+			wantNeighbours: map[string][]string{
+				"n1": {"n2"},
+				"n2": {"n0", "n1"},
+				"n0": {"n3", "n2"},
+				"n3": {"n0"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := NewLatencyTree(nodes, tt.maxChildren, fast)
+			if err != nil {
+				t.Fatalf("NewLatencyTree() error = %v", err)
+			}
+			if got := tree.Root(); got != tt.wantRoot {
+				t.Errorf("Root() = %v, want %v", got, tt.wantRoot)
+			}
+			for node, want := range tt.wantNeighbours {
+				if got, _ := tree.Neighbours(node); !reflect.DeepEqual(got, want) {
+					t.Errorf("Neighbours(%s) = %v, want %v", node, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewLatencyTree_Unmeasured(t *testing.T) {
+	for _, branching := range []int{1, 2, 3} {
+		nodes := nodeIDs(13)
+		tree := MustNewTree(nodes, branching)
+		latencyTree, err := NewLatencyTree(nodes, branching, nil)
+		if err != nil {
+			t.Fatalf("NewLatencyTree() error = %v", err)
+		}
+		for _, node := range nodes {
+			want, _ := tree.Neighbours(node)
+			if got, _ := latencyTree.Neighbours(node); !reflect.DeepEqual(got, want) {
+				t.Errorf("branching %d: Neighbours(%s) = %v, want %v", branching, node, got, want)
+			}
+		}
+	}
+}
+
+func randomLatencies(r *rand.Rand, nodes []string) Latencies {
+	latencies := Latencies{}
+	for _, a := range nodes {
+		latencies[a] = map[string]time.Duration{}
+		for _, b := range nodes {
+			if a != b && r.IntN(5) > 0 {
+				latencies[a][b] = time.Duration(1+r.IntN(200)) * time.Millisecond
+			}
+		}
+	}
+	return latencies
+}
+
+// kruskal returns the weight of a minimum spanning tree.
+func kruskal(nodes []string, latencies Latencies) time.Duration {
+	type link struct {
+		a, b int
+		rtt  time.Duration
+	}
+	var links []link
+	for a := range nodes {
+		for b := a + 1; b < len(nodes); b++ {
+			links = append(links, link{a, b, latencies.RTT(nodes[a], nodes[b])})
+		}
+	}
+	slices.SortFunc(links, func(x, y link) int { return int(x.rtt - y.rtt) })
+	component := make([]int, len(nodes))
+	for i := range component {
+		component[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if component[i] != i {
+			component[i] = find(component[i])
+		}
+		return component[i]
+	}
+	var total time.Duration
+	for _, l := range links {
+		if a, b := find(l.a), find(l.b); a != b {
+			component[a] = b
+			total += l.rtt
+		}
+	}
+	return total
+}
+
+func TestNewLatencyTree_Random(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+	nodes := nodeIDs(15)
+	for range 50 {
+		latencies := randomLatencies(r, nodes)
+		for _, maxChildren := range []int{0, 1, 2, 3} {
+			tree, err := NewLatencyTree(nodes, maxChildren, latencies)
+			if err != nil {
+				t.Fatalf("NewLatencyTree() error = %v", err)
+			}
+			var total time.Duration
+			for _, node := range nodes {
+				children, err := tree.Children(node)
+				if err != nil {
+					t.Fatalf("Children(%s) error = %v", node, err)
+				}
+				if maxChildren > 0 && len(children) > maxChildren {
+					t.Fatalf("%s has %d children, want at most %d", node, len(children), maxChildren)
+				}
+				if parent, _ := tree.Parent(node); parent != node {
+					total += latencies.RTT(node, parent)
+				}
+			}
+			if want := kruskal(nodes, latencies); maxChildren == 0 && total != want {
+				t.Errorf("tree weight = %v, want minimum %v", total, want)
+			}
+			if again, _ := NewLatencyTree(nodes, maxChildren, latencies); !reflect.DeepEqual(again, tree) {
+				t.Errorf("NewLatencyTree() is not deterministic")
+			}
+		}
+	}
+}
+
+func TestNewLatencyTree_Errors(t *testing.T) {
+	if _, err := NewLatencyTree(nil, 2, nil); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("NewLatencyTree() error = %v, want %v", err, ErrNoNodes)
+	}
+	if _, err := NewLatencyTree(nodeIDs(3), -1, nil); !errors.Is(err, ErrInvalidBranches) {
+		t.Errorf("NewLatencyTree() error = %v, want %v", err, ErrInvalidBranches)
+	}
+	if _, err := NewLatencyTree([]string{"n0", "n0"}, 2, nil); !errors.Is(err, ErrDuplicateNode) {
+		t.Errorf("NewLatencyTree() error = %v, want %v", err, ErrDuplicateNode)
+	}
+}
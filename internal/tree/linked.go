@@ -0,0 +1,72 @@
+package tree
+
+import "fmt"
+
+// linked is a tree of any shape, given by the parent and children of every
+// node rather than derived from a layout like Tree.
+type linked struct {
+	root     string
+	parent   map[string]string
+	children map[string][]string
+}
+
+func newLinked(size int) linked {
+	return linked{
+		parent:   make(map[string]string, size),
+		children: make(map[string][]string, size),
+	}
+}
+
+// place adds node below parent, which must have been placed before. The first
+// node placed is the root and is its own parent.
+func (l *linked) place(node, parent string) {
+	l.children[node] = []string{}
+	if len(l.parent) == 0 {
+		l.root = node
+		parent = node
+	} else {
+		l.children[parent] = append(l.children[parent], node)
+	}
+	l.parent[node] = parent
+}
+
+// Root returns the root of the tree.
+func (l *linked) Root() string {
+	return l.root
+}
+
+func (l *linked) lookup(node string) error {
+	if _, ok := l.parent[node]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownNode, node)
+	}
+	return nil
+}
+
+// Children returns the children of node.
+func (l *linked) Children(node string) ([]string, error) {
+	if err := l.lookup(node); err != nil {
+		return nil, err
+	}
+	return append([]string{}, l.children[node]...), nil
+}
+
+// Parent returns the parent of node. The root is its own parent.
+func (l *linked) Parent(node string) (string, error) {
+	if err := l.lookup(node); err != nil {
+		return "", err
+	}
+	return l.parent[node], nil
+}
+
+// Neighbours returns the children of node followed by its parent, if it has
+// one.
+func (l *linked) Neighbours(node string) ([]string, error) {
+	children, err := l.Children(node)
+	if err != nil {
+		return nil, err
+	}
+	if node == l.root {
+		return children, nil
+	}
+	return append(children, l.parent[node]), nil
+}
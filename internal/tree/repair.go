@@ -0,0 +1,93 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrSuspectedNode = errors.New("node suspected to have failed")
+
+// Repaired is a tree with its suspected-failed nodes routed around: every live
+// node is adopted by its nearest live ancestor. If the root has failed, the
+// first live node in the layout takes its place and also adopts the nodes that
+// have no live ancestor left.
+type Repaired struct {
+	linked
+	suspected map[string]struct{}
+}
+
+// Repair returns the tree without the suspected nodes. Suspected nodes that
+// are not in the tree are ignored. It fails only if every node is suspected.
+func (t *Tree) Repair(suspected []string) (*Repaired, error) {
+	r := &Repaired{
+		linked:    newLinked(len(t.arr)),
+		suspected: make(map[string]struct{}, len(suspected)),
+	}
+	for _, node := range suspected {
+		if _, ok := t.index[node]; ok {
+			r.suspected[node] = struct{}{}
+		}
+	}
+	isSuspected := func(nodeIndex int) bool {
+		_, ok := r.suspected[t.arr[nodeIndex]]
+		return ok
+	}
+
+	rootIndex := -1
+	for nodeIndex, node := range t.arr {
+		if isSuspected(nodeIndex) {
+			continue
+		}
+		if rootIndex == -1 {
+			rootIndex = nodeIndex
+			r.place(node, node)
+			continue
+		}
+		parentIndex := t.parentIndex(nodeIndex)
+		for parentIndex > 0 && isSuspected(parentIndex) {
+			parentIndex = t.parentIndex(parentIndex)
+		}
+		if isSuspected(parentIndex) {
+			parentIndex = rootIndex
+		}
+		// Ancestors come first in the layout, so the parent is already placed
+		r.place(node, t.arr[parentIndex])
+	}
+	if rootIndex == -1 {
+		return nil, fmt.Errorf("%w: all nodes are suspected", ErrNoNodes)
+	}
+	return r, nil
+}
+
+func (r *Repaired) check(node string) error {
+	if _, ok := r.suspected[node]; ok {
+		return fmt.Errorf("%w: %q", ErrSuspectedNode, node)
+	}
+	return nil
+}
+
+// Children returns the children of node, including those it adopted.
+func (r *Repaired) Children(node string) ([]string, error) {
+	if err := r.check(node); err != nil {
+		return nil, err
+	}
+	return r.linked.Children(node)
+}
+
+// Parent returns the nearest live ancestor of node. The root is its own
+// parent.
+func (r *Repaired) Parent(node string) (string, error) {
+	if err := r.check(node); err != nil {
+		return "", err
+	}
+	return r.linked.Parent(node)
+}
+
+// Neighbours returns the children of node followed by its parent, if it has
+// one.
+func (r *Repaired) Neighbours(node string) ([]string, error) {
+	if err := r.check(node); err != nil {
+		return nil, err
+	}
+	return r.linked.Neighbours(node)
+}
@@ -0,0 +1,164 @@
+package tree
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestTree_Repair(t *testing.T) {
+	tests := []struct {
+		name           string
+		nodes          int
+		branching      int
+		suspected      []string
+		wantRoot       string
+		wantNeighbours map[string][]string
+	}{
+		{
+			name:      "failed inner node is adopted around",
+			nodes:     10,
+			branching: 3,
+			suspected: []string{"n2"},
+			wantRoot:  "n0",
+			wantNeighbours: map[string][]string{
+				"n0": {"n1", "n3", "n7", "n8", "n9"},
+				"n7": {"n0"},
+				"n1": {"n4", "n5", "n6", "n0"},
+			},
+		},
+		{
+			name:      "failed root is replaced by the first live node",
+			nodes:     10,
+			branching: 3,
+			suspected: []string{"n0"},
+			wantRoot:  "n1",
+			wantNeighbours: map[string][]string{
+				"n1": {"n2", "n3", "n4", "n5", "n6"},
+				"n2": {"n7", "n8", "n9", "n1"},
+				"n3": {"n1"},
+			},
+		},
+		{
+			name:      "failed root and child",
+			nodes:     10,
+			branching: 3,
+			suspected: []string{"n0", "n1"},
+			wantRoot:  "n2",
+			wantNeighbours: map[string][]string{
+				"n2": {"n3", "n4", "n5", "n6", "n7", "n8", "n9"},
+				"n4": {"n2"},
+			},
+		},
+		{
+			name:      "consecutive failures in a chain",
+			nodes:     4,
+			branching: 1,
+			suspected: []string{"n1", "n2"},
+			wantRoot:  "n0",
+			wantNeighbours: map[string][]string{
+				"n0": {"n3"},
+				"n3": {"n0"},
+			},
+		},
+		{
+			name:      "unknown suspects are ignored",
+			nodes:     4,
+			branching: 2,
+			suspected: []string{"n9"},
+			wantRoot:  "n0",
+			wantNeighbours: map[string][]string{
+				"n0": {"n1", "n2"},
+				"n1": {"n3", "n0"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repaired, err := MustNewTree(nodeIDs(tt.nodes), tt.branching).Repair(tt.suspected)
+			if err != nil {
+				t.Fatalf("Repair() error = %v", err)
+			}
+			if got := repaired.Root(); got != tt.wantRoot {
+				t.Errorf("Root() = %v, want %v", got, tt.wantRoot)
+			}
+			for node, want := range tt.wantNeighbours {
+				got, err := repaired.Neighbours(node)
+				if err != nil {
+					t.Fatalf("Neighbours(%s) error = %v", node, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Neighbours(%s) = %v, want %v", node, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTree_Repair_NoSuspects(t *testing.T) {
+	tree := MustNewTree(nodeIDs(20), 3)
+	repaired, err := tree.Repair(nil)
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	for _, node := range nodeIDs(20) {
+		want, _ := tree.Neighbours(node)
+		if got, _ := repaired.Neighbours(node); !reflect.DeepEqual(got, want) {
+			t.Errorf("Neighbours(%s) = %v, want %v", node, got, want)
+		}
+	}
+}
+
+func TestTree_Repair_Errors(t *testing.T) {
+	tree := MustNewTree(nodeIDs(3), 2)
+	if _, err := tree.Repair(nodeIDs(3)); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("Repair() of all nodes error = %v, want %v", err, ErrNoNodes)
+	}
+	repaired, err := tree.Repair([]string{"n1"})
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	if _, err := repaired.Parent("n1"); !errors.Is(err, ErrSuspectedNode) {
+		t.Errorf("Parent() of suspected node error = %v, want %v", err, ErrSuspectedNode)
+	}
+	if _, err := repaired.Neighbours("n9"); !errors.Is(err, ErrUnknownNode) {
+		t.Errorf("Neighbours() of unknown node error = %v, want %v", err, ErrUnknownNode)
+	}
+}
+
+// Whatever fails, every live node reaches the root through its parents and is
+// a child of its parent
+func TestTree_Repair_Connected(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	nodes := nodeIDs(30)
+	for range 200 {
+		tree := MustNewTree(nodes, 1+r.IntN(4))
+		var suspected []string
+		for _, node := range nodes {
+			if r.IntN(3) == 0 {
+				suspected = append(suspected, node)
+			}
+		}
+		repaired, err := tree.Repair(suspected)
+		if err != nil {
+			t.Fatalf("Repair(%v) error = %v", suspected, err)
+		}
+		for _, node := range nodes {
+			if slices.Contains(suspected, node) {
+				continue
+			}
+			parent, _ := repaired.Parent(node)
+			if children, _ := repaired.Children(parent); node != repaired.Root() && !slices.Contains(children, node) {
+				t.Fatalf("Repair(%v): %s is not a child of its parent %s", suspected, node, parent)
+			}
+			for hops := 0; node != repaired.Root(); hops++ {
+				if hops > len(nodes) || slices.Contains(suspected, node) {
+					t.Fatalf("Repair(%v): no live path from %s to the root", suspected, node)
+				}
+				node, _ = repaired.Parent(node)
+			}
+		}
+	}
+}
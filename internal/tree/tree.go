@@ -1,37 +1,203 @@
 package tree
 
 import (
+	"errors"
+	"fmt"
 	"slices"
 )
 
+var (
+	ErrNoNodes         = errors.New("tree has no nodes")
+	ErrInvalidBranches = errors.New("branching must be at least 1")
+	ErrDuplicateNode   = errors.New("duplicate node")
+	ErrUnknownNode     = errors.New("node not known")
+)
+
+// Tree lays nodes out as a complete tree with the given branching factor, in
+// the order they are given: the first node is the root.
 type Tree struct {
 	arr       []string
+	index     map[string]int
 	branching int
 }
 
-func NewTree(nodes []string, branching int) *Tree {
-	//slices.Sort(nodes)
-	//TODO: unique check
+// NewTree validates nodes and branching and returns the tree over a copy of
+// nodes.
+func NewTree(nodes []string, branching int) (*Tree, error) {
+	index, err := indexNodes(nodes)
+	if err != nil {
+		return nil, err
+	}
+	if branching < 1 {
+		return nil, fmt.Errorf("%w, got %d", ErrInvalidBranches, branching)
+	}
 	return &Tree{
-		arr:       nodes,
+		arr:       slices.Clone(nodes),
+		index:     index,
 		branching: branching,
+	}, nil
+}
+
+// indexNodes returns the position of every node, after checking that nodes
+// is non-empty and free of duplicates.
+func indexNodes(nodes []string) (map[string]int, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	index := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		if _, ok := index[node]; ok {
+			return nil, fmt.Errorf("%w %q", ErrDuplicateNode, node)
+		}
+		index[node] = i
 	}
+	return index, nil
 }
 
-func (t *Tree) Children(node string) []string {
-	nodeIndex := slices.Index(t.arr, node)
-	if nodeIndex == -1 {
-		panic("node not known")
+// MustNewTree is like NewTree but panics on invalid input.
+func MustNewTree(nodes []string, branching int) *Tree {
+	t, err := NewTree(nodes, branching)
+	if err != nil {
+		panic(err)
 	}
-	firstNeighbour := min(t.branching*nodeIndex+1, len(t.arr))
-	lastNeighbour := min(t.branching*nodeIndex+t.branching, len(t.arr)-1)
-	return t.arr[firstNeighbour : lastNeighbour+1]
+	return t
 }
 
-func (t *Tree) Parent(node string) string {
-	nodeIndex := slices.Index(t.arr, node)
-	if nodeIndex == -1 {
-		panic("node not known")
+// lookup returns the position of node in the layout.
+func (t *Tree) lookup(node string) (int, error) {
+	nodeIndex, ok := t.index[node]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownNode, node)
+	}
+	return nodeIndex, nil
+}
+
+// childRange returns the indexes [first, last] of the children of the nodes
+// at indexes [lo, hi]. The range is empty, first > last, for leaves.
+func (t *Tree) childRange(lo, hi int) (int, int) {
+	return min(t.branching*lo+1, len(t.arr)), min(t.branching*hi+t.branching, len(t.arr)-1)
+}
+
+func (t *Tree) parentIndex(nodeIndex int) int {
+	return (nodeIndex - 1) / t.branching
+}
+
+// Children returns the children of node. The slice is the caller's to modify.
+func (t *Tree) Children(node string) ([]string, error) {
+	nodeIndex, err := t.lookup(node)
+	if err != nil {
+		return nil, err
+	}
+	firstNeighbour, lastNeighbour := t.childRange(nodeIndex, nodeIndex)
+	return slices.Clone(t.arr[firstNeighbour : lastNeighbour+1]), nil
+}
+
+// MustChildren is like Children but panics on an unknown node.
+func (t *Tree) MustChildren(node string) []string {
+	children, err := t.Children(node)
+	if err != nil {
+		panic(err)
+	}
+	return children
+}
+
+// Parent returns the parent of node. The root is its own parent.
+func (t *Tree) Parent(node string) (string, error) {
+	nodeIndex, err := t.lookup(node)
+	if err != nil {
+		return "", err
+	}
+	return t.arr[t.parentIndex(nodeIndex)], nil
+}
+
+// MustParent is like Parent but panics on an unknown node.
+func (t *Tree) MustParent(node string) string {
+	parent, err := t.Parent(node)
+	if err != nil {
+		panic(err)
+	}
+	return parent
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() string {
+	return t.arr[0]
+}
+
+// IsRoot reports whether node is the root. Unknown nodes are not.
+func (t *Tree) IsRoot(node string) bool {
+	return node == t.arr[0]
+}
+
+// Depth returns the number of hops from the root to node.
+func (t *Tree) Depth(node string) (int, error) {
+	nodeIndex, err := t.lookup(node)
+	if err != nil {
+		return 0, err
+	}
+	depth := 0
+	for ; nodeIndex > 0; nodeIndex = t.parentIndex(nodeIndex) {
+		depth++
+	}
+	return depth, nil
+}
+
+// Ancestors returns the path from the parent of node up to the root. It is
+// empty for the root.
+func (t *Tree) Ancestors(node string) ([]string, error) {
+	nodeIndex, err := t.lookup(node)
+	if err != nil {
+		return nil, err
+	}
+	ancestors := []string{}
+	for nodeIndex > 0 {
+		nodeIndex = t.parentIndex(nodeIndex)
+		ancestors = append(ancestors, t.arr[nodeIndex])
+	}
+	return ancestors, nil
+}
+
+// Subtree returns node and all its descendants, level by level. The
+// descendants on each level are adjacent in the layout, so every level is a
+// single copy.
+func (t *Tree) Subtree(node string) ([]string, error) {
+	nodeIndex, err := t.lookup(node)
+	if err != nil {
+		return nil, err
+	}
+	subtree := []string{}
+	for lo, hi := nodeIndex, nodeIndex; lo <= hi; lo, hi = t.childRange(lo, hi) {
+		subtree = append(subtree, t.arr[lo:hi+1]...)
+	}
+	return subtree, nil
+}
+
+// Leaves returns the nodes without children, which are the tail of the
+// layout.
+func (t *Tree) Leaves() []string {
+	return slices.Clone(t.arr[t.firstLeaf():])
+}
+
+// Inner returns the nodes with children, which are the head of the layout.
+func (t *Tree) Inner() []string {
+	return slices.Clone(t.arr[:t.firstLeaf()])
+}
+
+func (t *Tree) firstLeaf() int {
+	return (len(t.arr) - 1 + t.branching - 1) / t.branching
+}
+
+// Neighbours returns the children of node followed by its parent, if it has
+// one: the peers node talks to when broadcasting over the tree.
+func (t *Tree) Neighbours(node string) ([]string, error) {
+	nodeIndex, err := t.lookup(node)
+	if err != nil {
+		return nil, err
+	}
+	first, last := t.childRange(nodeIndex, nodeIndex)
+	neighbours := slices.Clone(t.arr[first : last+1])
+	if nodeIndex == 0 {
+		return neighbours, nil
 	}
-	return t.arr[(nodeIndex-1)/t.branching]
+	return append(neighbours, t.arr[t.parentIndex(nodeIndex)]), nil
 }
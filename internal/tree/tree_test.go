@@ -1,7 +1,10 @@
 package tree
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"slices"
 	"testing"
 )
 
@@ -121,8 +124,12 @@ func TestTree_Children(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tree := NewTree(tt.fields.arr, tt.fields.branching)
-			if got := tree.Children(tt.args.node); !reflect.DeepEqual(got, tt.want) {
+			tree := MustNewTree(tt.fields.arr, tt.fields.branching)
+			got, err := tree.Children(tt.args.node)
+			if err != nil {
+				t.Fatalf("Children() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Children() = %v, want %v", got, tt.want)
 			}
 		})
@@ -173,10 +180,213 @@ func TestTree_Parent(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tree := NewTree(tt.fields.arr, tt.fields.branching)
-			if got := tree.Parent(tt.args.node); !reflect.DeepEqual(got, tt.want) {
+			tree := MustNewTree(tt.fields.arr, tt.fields.branching)
+			got, err := tree.Parent(tt.args.node)
+			if err != nil {
+				t.Fatalf("Parent() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Parent() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestNewTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		nodes     []string
+		branching int
+		wantErr   error
+	}{
+		{name: "valid", nodes: []string{"n0", "n1", "n2"}, branching: 2},
+		{name: "single node", nodes: []string{"n0"}, branching: 1},
+		{name: "no nodes", nodes: nil, branching: 2, wantErr: ErrNoNodes},
+		{name: "zero branching", nodes: []string{"n0", "n1"}, branching: 0, wantErr: ErrInvalidBranches},
+		{name: "negative branching", nodes: []string{"n0", "n1"}, branching: -1, wantErr: ErrInvalidBranches},
+		{name: "duplicate node", nodes: []string{"n0", "n1", "n0"}, branching: 2, wantErr: ErrDuplicateNode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTree(tt.nodes, tt.branching)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewTree() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTree_UnknownNode(t *testing.T) {
+	tree := MustNewTree([]string{"n0", "n1", "n2"}, 2)
+	if _, err := tree.Children("n9"); !errors.Is(err, ErrUnknownNode) {
+		t.Errorf("Children() error = %v, want %v", err, ErrUnknownNode)
+	}
+	if _, err := tree.Parent("n9"); !errors.Is(err, ErrUnknownNode) {
+		t.Errorf("Parent() error = %v, want %v", err, ErrUnknownNode)
+	}
+}
+
+func TestTree_Must(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"MustNewTree", func() { MustNewTree(nil, 2) }},
+		{"MustChildren", func() { MustNewTree([]string{"n0"}, 2).MustChildren("n9") }},
+		{"MustParent", func() { MustNewTree([]string{"n0"}, 2).MustParent("n9") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s() did not panic", tt.name)
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+// Children hands out its own slice, so appending to it leaves the tree intact
+func TestTree_Children_DoesNotAlias(t *testing.T) {
+	tree := MustNewTree([]string{"n0", "n1", "n2", "n3"}, 1)
+	_ = append(tree.MustChildren("n0"), "x")
+	if got := tree.MustChildren("n1"); !reflect.DeepEqual(got, []string{"n2"}) {
+		t.Errorf("Children() after append to earlier result = %v, want [n2]", got)
+	}
+}
+
+func nodeIDs(n int) []string {
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = fmt.Sprint("n", i)
+	}
+	return nodes
+}
+
+func TestTree_Traversal(t *testing.T) {
+	ternary := MustNewTree(nodeIDs(10), 3)
+	binary := MustNewTree(nodeIDs(7), 2)
+	chain := MustNewTree(nodeIDs(4), 1)
+	tests := []struct {
+		name string
+		got  func() (any, error)
+		want any
+	}{
+		{"Depth of root", func() (any, error) { return ternary.Depth("n0") }, 0},
+		{"Depth of child", func() (any, error) { return ternary.Depth("n3") }, 1},
+		{"Depth of leaf", func() (any, error) { return ternary.Depth("n9") }, 2},
+		{"Depth in chain", func() (any, error) { return chain.Depth("n3") }, 3},
+		{"Ancestors of root", func() (any, error) { return ternary.Ancestors("n0") }, []string{}},
+		{"Ancestors of leaf", func() (any, error) { return ternary.Ancestors("n9") }, []string{"n2", "n0"}},
+		{"Ancestors in chain", func() (any, error) { return chain.Ancestors("n3") }, []string{"n2", "n1", "n0"}},
+		{"Subtree of root", func() (any, error) { return binary.Subtree("n0") }, nodeIDs(7)},
+		{"Subtree of inner node", func() (any, error) { return ternary.Subtree("n2") }, []string{"n2", "n7", "n8", "n9"}},
+		{"Subtree of inner binary node", func() (any, error) { return binary.Subtree("n1") }, []string{"n1", "n3", "n4"}},
+		{"Subtree of leaf", func() (any, error) { return ternary.Subtree("n5") }, []string{"n5"}},
+		{"Neighbours of root", func() (any, error) { return ternary.Neighbours("n0") }, []string{"n1", "n2", "n3"}},
+		{"Neighbours of inner node", func() (any, error) { return ternary.Neighbours("n2") }, []string{"n7", "n8", "n9", "n0"}},
+		{"Neighbours of leaf", func() (any, error) { return ternary.Neighbours("n9") }, []string{"n2"}},
+		{"Neighbours of single node", func() (any, error) { return MustNewTree([]string{"n0"}, 2).Neighbours("n0") }, []string{}},
+		{"Leaves", func() (any, error) { return ternary.Leaves(), nil }, []string{"n3", "n4", "n5", "n6", "n7", "n8", "n9"}},
+		{"Leaves of binary tree", func() (any, error) { return binary.Leaves(), nil }, []string{"n3", "n4", "n5", "n6"}},
+		{"Leaves of chain", func() (any, error) { return chain.Leaves(), nil }, []string{"n3"}},
+		{"Leaves of single node", func() (any, error) { return MustNewTree([]string{"n0"}, 2).Leaves(), nil }, []string{"n0"}},
+		{"Inner", func() (any, error) { return ternary.Inner(), nil }, []string{"n0", "n1", "n2"}},
+		{"Inner of single node", func() (any, error) { return MustNewTree([]string{"n0"}, 2).Inner(), nil }, []string{}},
+		{"IsRoot of root", func() (any, error) { return ternary.IsRoot("n0"), nil }, true},
+		{"IsRoot of child", func() (any, error) { return ternary.IsRoot("n1"), nil }, false},
+		{"IsRoot of unknown node", func() (any, error) { return ternary.IsRoot("n99"), nil }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.got()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_Traversal_UnknownNode(t *testing.T) {
+	tree := MustNewTree(nodeIDs(3), 2)
+	for name, fn := range map[string]func(string) error{
+		"Depth":      func(n string) error { _, err := tree.Depth(n); return err },
+		"Ancestors":  func(n string) error { _, err := tree.Ancestors(n); return err },
+		"Subtree":    func(n string) error { _, err := tree.Subtree(n); return err },
+		"Neighbours": func(n string) error { _, err := tree.Neighbours(n); return err },
+	} {
+		if err := fn("n9"); !errors.Is(err, ErrUnknownNode) {
+			t.Errorf("%s() error = %v, want %v", name, err, ErrUnknownNode)
+		}
+	}
+}
+
+// Every node lies in the subtree of each of its ancestors, at the depth given
+// by the number of its ancestors
+func TestTree_Traversal_Consistent(t *testing.T) {
+	for _, branching := range []int{1, 2, 3, 5} {
+		tree := MustNewTree(nodeIDs(40), branching)
+		for _, node := range nodeIDs(40) {
+			ancestors, _ := tree.Ancestors(node)
+			if depth, _ := tree.Depth(node); depth != len(ancestors) {
+				t.Errorf("branching %d: Depth(%s) = %d, want %d", branching, node, depth, len(ancestors))
+			}
+			for _, ancestor := range ancestors {
+				if subtree, _ := tree.Subtree(ancestor); !slices.Contains(subtree, node) {
+					t.Errorf("branching %d: Subtree(%s) = %v, missing %s", branching, ancestor, subtree, node)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkNewTree(b *testing.B) {
+	nodes := nodeIDs(1000)
+	for b.Loop() {
+		MustNewTree(nodes, 4)
+	}
+}
+
+func BenchmarkTree(b *testing.B) {
+	tree := MustNewTree(nodeIDs(1000), 4)
+	node := "n777"
+	b.Run("Children", func(b *testing.B) {
+		for b.Loop() {
+			tree.MustChildren(node)
+		}
+	})
+	b.Run("Parent", func(b *testing.B) {
+		for b.Loop() {
+			tree.MustParent(node)
+		}
+	})
+	b.Run("Neighbours", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = tree.Neighbours(node)
+		}
+	})
+	b.Run("Depth", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = tree.Depth(node)
+		}
+	})
+	b.Run("Ancestors", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = tree.Ancestors(node)
+		}
+	})
+	b.Run("Subtree of root", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = tree.Subtree("n0")
+		}
+	})
+	b.Run("Leaves", func(b *testing.B) {
+		for b.Loop() {
+			tree.Leaves()
+		}
+	})
+}